@@ -50,17 +50,17 @@ type PaperConfig struct {
 }
 
 type PaperResult struct {
-	Threshold float64
+	Threshold float64 `json:"threshold"`
 
-	Predictions int
-	Trades      int
-	Coverage    float64
+	Predictions int     `json:"predictions"`
+	Trades      int     `json:"trades"`
+	Coverage    float64 `json:"coverage"`
 
-	TotalReturn float64 // ending_equity - 1
-	Sharpe      float64
-	MaxDrawdown float64
+	TotalReturn float64 `json:"total_return"` // ending_equity - 1
+	Sharpe      float64 `json:"sharpe"`
+	MaxDrawdown float64 `json:"max_drawdown"`
 
-	EndEquity float64
+	EndEquity float64 `json:"end_equity"`
 }
 
 func LoadPredictionsWithForwardReturn(
@@ -0,0 +1,199 @@
+package candlefile
+
+import (
+	"fmt"
+	"strings"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+// Candle fields that can be mapped to file columns. Field names match the candles table.
+const (
+	FieldExchange  = "exchange"
+	FieldSymbol    = "symbol"
+	FieldTimeframe = "timeframe"
+	FieldTimestamp = "timestamp"
+	FieldOpen      = "open"
+	FieldHigh      = "high"
+	FieldLow       = "low"
+	FieldClose     = "close"
+	FieldVolume    = "volume"
+	FieldCloseTime = "close_time"
+	FieldIsFinal   = "is_final"
+
+	FieldQuoteVolume         = "quote_volume"
+	FieldTrades              = "trades"
+	FieldTakerBuyBaseVolume  = "taker_buy_base_volume"
+	FieldTakerBuyQuoteVolume = "taker_buy_quote_volume"
+)
+
+// Fields is the export column order.
+var Fields = []string{
+	FieldExchange,
+	FieldSymbol,
+	FieldTimeframe,
+	FieldTimestamp,
+	FieldOpen,
+	FieldHigh,
+	FieldLow,
+	FieldClose,
+	FieldVolume,
+	FieldCloseTime,
+	FieldIsFinal,
+	FieldQuoteVolume,
+	FieldTrades,
+	FieldTakerBuyBaseVolume,
+	FieldTakerBuyQuoteVolume,
+}
+
+var requiredFields = []string{FieldTimestamp, FieldOpen, FieldHigh, FieldLow, FieldClose, FieldVolume}
+
+// ColumnMapping maps candle field -> file column name.
+type ColumnMapping map[string]string
+
+func DefaultColumnMapping() ColumnMapping {
+	mapping := ColumnMapping{}
+	for _, field := range Fields {
+		mapping[field] = field
+	}
+	return mapping
+}
+
+// ParseColumnMapping applies "field=column,field=column" overrides on top of the default
+// (identity) mapping, e.g. "timestamp=open_time,volume=base_volume".
+func ParseColumnMapping(value string) (ColumnMapping, error) {
+	mapping := DefaultColumnMapping()
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		field, column, found := strings.Cut(pair, "=")
+		field = strings.TrimSpace(field)
+		column = strings.TrimSpace(column)
+		if !found || column == "" {
+			return nil, fmt.Errorf("invalid column mapping %q (want field=column)", pair)
+		}
+		if _, known := mapping[field]; !known {
+			return nil, fmt.Errorf("unknown candle field %q (fields: %s)", field, strings.Join(Fields, ", "))
+		}
+		mapping[field] = column
+	}
+
+	seen := map[string]string{}
+	for _, field := range Fields {
+		column := mapping[field]
+		if other, duplicate := seen[column]; duplicate {
+			return nil, fmt.Errorf("column %q mapped to both %s and %s", column, other, field)
+		}
+		seen[column] = field
+	}
+	return mapping, nil
+}
+
+// TimestampUnit is the unit of the timestamp and close_time columns in a file.
+// Candles always use epoch milliseconds internally.
+type TimestampUnit string
+
+const (
+	UnitSeconds      TimestampUnit = "s"
+	UnitMilliseconds TimestampUnit = "ms"
+	UnitMicroseconds TimestampUnit = "us"
+	UnitNanoseconds  TimestampUnit = "ns"
+)
+
+func ParseTimestampUnit(value string) (TimestampUnit, error) {
+	switch TimestampUnit(strings.ToLower(strings.TrimSpace(value))) {
+	case UnitSeconds:
+		return UnitSeconds, nil
+	case UnitMilliseconds, "":
+		return UnitMilliseconds, nil
+	case UnitMicroseconds:
+		return UnitMicroseconds, nil
+	case UnitNanoseconds:
+		return UnitNanoseconds, nil
+	default:
+		return "", fmt.Errorf("unsupported timestamp unit %q (s, ms, us, ns)", value)
+	}
+}
+
+// fromMillis converts epoch ms to the file unit. Seconds drop sub-second precision; bar open
+// times are whole seconds, and closeTimeToMillis restores the …999 ms of a close_time.
+func (unit TimestampUnit) fromMillis(millis int64) int64 {
+	switch unit {
+	case UnitSeconds:
+		return millis / 1000
+	case UnitMicroseconds:
+		return millis * 1000
+	case UnitNanoseconds:
+		return millis * 1000 * 1000
+	default:
+		return millis
+	}
+}
+
+func (unit TimestampUnit) toMillis(value int64) int64 {
+	switch unit {
+	case UnitSeconds:
+		return value * 1000
+	case UnitMicroseconds:
+		return value / 1000
+	case UnitNanoseconds:
+		return value / (1000 * 1000)
+	default:
+		return value
+	}
+}
+
+// closeTimeToMillis converts a close_time from the file unit. In seconds a close_time stands
+// for the last millisecond of that second (close times are the next bar open - 1ms), so a
+// seconds export reads back to the same close_time.
+func (unit TimestampUnit) closeTimeToMillis(value int64) int64 {
+	if unit == UnitSeconds {
+		return value*1000 + 999
+	}
+	return unit.toMillis(value)
+}
+
+type Options struct {
+	Columns       ColumnMapping
+	TimestampUnit TimestampUnit
+
+	// Used on import when the file has no exchange/symbol/timeframe column
+	Exchange  string
+	Symbol    string
+	Timeframe string
+}
+
+func (options Options) columns() ColumnMapping {
+	if options.Columns == nil {
+		return DefaultColumnMapping()
+	}
+	return options.Columns
+}
+
+// completeCandle fills the series key from options and derives a missing close_time
+// (Binance convention: next bar open - 1ms), then checks the key is set.
+func (options Options) completeCandle(candle *candles.Candle, row int) error {
+	if candle.Exchange == "" {
+		candle.Exchange = options.Exchange
+	}
+	if candle.Symbol == "" {
+		candle.Symbol = options.Symbol
+	}
+	if candle.Timeframe == "" {
+		candle.Timeframe = options.Timeframe
+	}
+	if candle.Exchange == "" || candle.Symbol == "" || candle.Timeframe == "" {
+		return fmt.Errorf("row %d: exchange/symbol/timeframe missing (add the columns or pass them as flags)", row)
+	}
+
+	if candle.CloseTime == 0 {
+		timeframe, err := candles.ParseTimeframe(candle.Timeframe)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		candle.CloseTime = timeframe.CloseTime(candle.Timestamp)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package candlefile
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+// WriteCSV writes a header row and one row per candle. Floats use the shortest representation
+// that parses back to the same float64, so an export/import cycle is lossless.
+func WriteCSV(writer io.Writer, candleSeries []candles.Candle, options Options) error {
+	columns := options.columns()
+	csvWriter := csv.NewWriter(writer)
+
+	header := make([]string, len(Fields))
+	for i, field := range Fields {
+		header[i] = columns[field]
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	for _, candle := range candleSeries {
+		isFinal := "0"
+		if candle.IsFinal {
+			isFinal = "1"
+		}
+		record := []string{
+			candle.Exchange,
+			candle.Symbol,
+			candle.Timeframe,
+			strconv.FormatInt(options.TimestampUnit.fromMillis(candle.Timestamp), 10),
+			formatFloat(candle.Open),
+			formatFloat(candle.High),
+			formatFloat(candle.Low),
+			formatFloat(candle.Close),
+			formatFloat(candle.Volume),
+			strconv.FormatInt(options.TimestampUnit.fromMillis(candle.CloseTime), 10),
+			isFinal,
+			formatOptionalFloat(candle.QuoteVolume),
+			formatOptionalInt(candle.Trades),
+			formatOptionalFloat(candle.TakerBuyBaseVolume),
+			formatOptionalFloat(candle.TakerBuyQuoteVolume),
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// ReadCSV reads candles from a CSV with a header row. Columns are located by name through
+// options.Columns; exchange/symbol/timeframe/close_time/is_final and the order-flow columns are
+// optional, and an empty order-flow cell reads as unknown (nil).
+func ReadCSV(reader io.Reader, options Options) ([]candles.Candle, error) {
+	columns := options.columns()
+	csvReader := csv.NewReader(reader)
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	columnIndex := map[string]int{}
+	for i, name := range header {
+		columnIndex[strings.TrimSpace(name)] = i
+	}
+
+	fieldIndex := map[string]int{}
+	for _, field := range Fields {
+		if i, ok := columnIndex[columns[field]]; ok {
+			fieldIndex[field] = i
+		}
+	}
+	for _, field := range requiredFields {
+		if _, ok := fieldIndex[field]; !ok {
+			return nil, fmt.Errorf("csv is missing column %q for %s", columns[field], field)
+		}
+	}
+
+	var result []candles.Candle
+	for row := 1; ; row++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value := func(field string) (string, bool) {
+			i, ok := fieldIndex[field]
+			if !ok || i >= len(record) {
+				return "", false
+			}
+			return strings.TrimSpace(record[i]), true
+		}
+
+		candle := candles.Candle{IsFinal: true}
+		candle.Exchange, _ = value(FieldExchange)
+		candle.Symbol, _ = value(FieldSymbol)
+		candle.Timeframe, _ = value(FieldTimeframe)
+
+		for _, target := range []struct {
+			field   string
+			into    *int64
+			convert func(int64) int64
+		}{
+			{FieldTimestamp, &candle.Timestamp, options.TimestampUnit.toMillis},
+			{FieldCloseTime, &candle.CloseTime, options.TimestampUnit.closeTimeToMillis},
+		} {
+			text, ok := value(target.field)
+			if !ok || text == "" {
+				continue
+			}
+			parsed, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %s: %w", row, target.field, err)
+			}
+			*target.into = target.convert(parsed)
+		}
+
+		for _, target := range []struct {
+			field string
+			into  *float64
+		}{
+			{FieldOpen, &candle.Open},
+			{FieldHigh, &candle.High},
+			{FieldLow, &candle.Low},
+			{FieldClose, &candle.Close},
+			{FieldVolume, &candle.Volume},
+		} {
+			text, _ := value(target.field)
+			parsed, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %s: %w", row, target.field, err)
+			}
+			*target.into = parsed
+		}
+
+		if text, ok := value(FieldIsFinal); ok && text != "" {
+			isFinal, err := strconv.ParseBool(text)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: is_final: %w", row, err)
+			}
+			candle.IsFinal = isFinal
+		}
+
+		for _, target := range []struct {
+			field string
+			into  **float64
+		}{
+			{FieldQuoteVolume, &candle.QuoteVolume},
+			{FieldTakerBuyBaseVolume, &candle.TakerBuyBaseVolume},
+			{FieldTakerBuyQuoteVolume, &candle.TakerBuyQuoteVolume},
+		} {
+			text, ok := value(target.field)
+			if !ok || text == "" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %s: %w", row, target.field, err)
+			}
+			*target.into = &parsed
+		}
+		if text, ok := value(FieldTrades); ok && text != "" {
+			trades, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: trades: %w", row, err)
+			}
+			candle.Trades = &trades
+		}
+
+		if err := options.completeCandle(&candle, row); err != nil {
+			return nil, err
+		}
+		result = append(result, candle)
+	}
+
+	return result, nil
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return formatFloat(*value)
+}
+
+func formatOptionalInt(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
@@ -0,0 +1,69 @@
+package candlefile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+)
+
+// ResolveFormat returns the explicit format if given, otherwise infers it from the file extension.
+func ResolveFormat(explicit string, path string) (Format, error) {
+	value := strings.ToLower(strings.TrimSpace(explicit))
+	if value == "" {
+		value = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	switch value {
+	case "csv":
+		return FormatCSV, nil
+	case "parquet", "pq":
+		return FormatParquet, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q (csv or parquet)", value)
+	}
+}
+
+func ReadFile(path string, format Format, options Options) ([]candles.Candle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if format == FormatParquet {
+		return ReadParquet(file, options)
+	}
+	return ReadCSV(file, options)
+}
+
+func WriteFile(path string, format Format, candleSeries []candles.Candle, options Options) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if format == FormatParquet {
+		err = WriteParquet(file, candleSeries, options)
+	} else {
+		err = WriteCSV(file, candleSeries, options)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package candlefile
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+func roundTripSeries() []candles.Candle {
+	const fourHours = 4 * 60 * 60 * 1000
+	quoteVolume, takerBase, takerQuote := 1262.5, 6.0, 606.25
+	trades := int64(42)
+	series := []candles.Candle{
+		// Order flow known
+		{Open: 61130.98, High: 61600, Low: 61000.01, Close: 61500, Volume: 20.5,
+			QuoteVolume: &quoteVolume, Trades: &trades, TakerBuyBaseVolume: &takerBase, TakerBuyQuoteVolume: &takerQuote},
+		// Order flow unknown (NULL in the candles table)
+		{Open: 61500, High: 61950.5, Low: 60900, Close: 61820.12, Volume: 0.1 + 0.2},
+		// Still forming
+		{Open: 61820.12, High: 62450.01, Low: 61100.5, Close: 62001.99, Volume: 1234.5678},
+	}
+	for i := range series {
+		open := int64(1709251200000 + i*fourHours)
+		series[i].Exchange, series[i].Symbol, series[i].Timeframe = "binance", "BTCUSDT", "4h"
+		series[i].Timestamp, series[i].CloseTime, series[i].IsFinal = open, open+fourHours-1, i < 2
+	}
+	return series
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	want := roundTripSeries()
+	for _, format := range []Format{FormatCSV, FormatParquet} {
+		for _, unit := range []TimestampUnit{UnitSeconds, UnitMilliseconds, UnitMicroseconds} {
+			t.Run(string(format)+"/"+string(unit), func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "candles."+string(format))
+				options := Options{TimestampUnit: unit}
+				if err := WriteFile(path, format, want, options); err != nil {
+					t.Fatal(err)
+				}
+				got, err := ReadFile(path, format, options)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("round trip changed the series:\n got  %+v\n want %+v", got, want)
+				}
+			})
+		}
+	}
+}
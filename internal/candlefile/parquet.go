@@ -0,0 +1,214 @@
+package candlefile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/parquet-go/parquet-go"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+// WriteParquet writes candles with the mapped column names. Timestamps are plain INT64 in
+// options.TimestampUnit (not a logical timestamp type) so the unit matches the CSV export.
+func WriteParquet(writer io.Writer, candleSeries []candles.Candle, options Options) error {
+	columns := options.columns()
+
+	schema := parquet.NewSchema("candles", parquet.Group{
+		columns[FieldExchange]:  parquet.String(),
+		columns[FieldSymbol]:    parquet.String(),
+		columns[FieldTimeframe]: parquet.String(),
+		columns[FieldTimestamp]: parquet.Leaf(parquet.Int64Type),
+		columns[FieldOpen]:      parquet.Leaf(parquet.DoubleType),
+		columns[FieldHigh]:      parquet.Leaf(parquet.DoubleType),
+		columns[FieldLow]:       parquet.Leaf(parquet.DoubleType),
+		columns[FieldClose]:     parquet.Leaf(parquet.DoubleType),
+		columns[FieldVolume]:    parquet.Leaf(parquet.DoubleType),
+		columns[FieldCloseTime]: parquet.Leaf(parquet.Int64Type),
+		columns[FieldIsFinal]:   parquet.Leaf(parquet.BooleanType),
+
+		columns[FieldQuoteVolume]:         parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		columns[FieldTrades]:              parquet.Optional(parquet.Leaf(parquet.Int64Type)),
+		columns[FieldTakerBuyBaseVolume]:  parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		columns[FieldTakerBuyQuoteVolume]: parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+	})
+
+	parquetWriter := parquet.NewWriter(writer, schema)
+	for _, candle := range candleSeries {
+		row := map[string]any{
+			columns[FieldExchange]:  candle.Exchange,
+			columns[FieldSymbol]:    candle.Symbol,
+			columns[FieldTimeframe]: candle.Timeframe,
+			columns[FieldTimestamp]: options.TimestampUnit.fromMillis(candle.Timestamp),
+			columns[FieldOpen]:      candle.Open,
+			columns[FieldHigh]:      candle.High,
+			columns[FieldLow]:       candle.Low,
+			columns[FieldClose]:     candle.Close,
+			columns[FieldVolume]:    candle.Volume,
+			columns[FieldCloseTime]: options.TimestampUnit.fromMillis(candle.CloseTime),
+			columns[FieldIsFinal]:   candle.IsFinal,
+
+			columns[FieldQuoteVolume]:         optionalValue(candle.QuoteVolume),
+			columns[FieldTrades]:              optionalValue(candle.Trades),
+			columns[FieldTakerBuyBaseVolume]:  optionalValue(candle.TakerBuyBaseVolume),
+			columns[FieldTakerBuyQuoteVolume]: optionalValue(candle.TakerBuyQuoteVolume),
+		}
+		if err := parquetWriter.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return parquetWriter.Close()
+}
+
+// ReadParquet reads candles from a Parquet file, locating columns by name through options.Columns.
+// Integer and floating point physical types are both accepted for numeric columns.
+func ReadParquet(file *os.File, options Options) ([]candles.Candle, error) {
+	columns := options.columns()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	parquetFile, err := parquet.OpenFile(file, info.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	present := map[string]bool{}
+	for _, field := range parquetFile.Schema().Fields() {
+		present[field.Name()] = true
+	}
+	for _, field := range requiredFields {
+		if !present[columns[field]] {
+			return nil, fmt.Errorf("parquet is missing column %q for %s", columns[field], field)
+		}
+	}
+
+	parquetReader := parquet.NewReader(parquetFile)
+	defer parquetReader.Close()
+
+	var result []candles.Candle
+	for row := 1; ; row++ {
+		values := map[string]any{}
+		if err := parquetReader.Read(&values); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		candle := candles.Candle{IsFinal: true}
+		var convertErr error
+		stringValue := func(field string) string {
+			text, _ := values[columns[field]].(string)
+			return text
+		}
+		int64Value := func(field string) int64 {
+			value, ok := values[columns[field]]
+			if !ok || value == nil || convertErr != nil {
+				return 0
+			}
+			converted, err := toInt64(value)
+			if err != nil {
+				convertErr = fmt.Errorf("row %d: %s: %w", row, field, err)
+			}
+			return converted
+		}
+		float64Value := func(field string) float64 {
+			value := values[columns[field]]
+			if convertErr != nil {
+				return 0
+			}
+			converted, err := toFloat64(value)
+			if err != nil {
+				convertErr = fmt.Errorf("row %d: %s: %w", row, field, err)
+			}
+			return converted
+		}
+
+		candle.Exchange = stringValue(FieldExchange)
+		candle.Symbol = stringValue(FieldSymbol)
+		candle.Timeframe = stringValue(FieldTimeframe)
+		candle.Timestamp = options.TimestampUnit.toMillis(int64Value(FieldTimestamp))
+		candle.Open = float64Value(FieldOpen)
+		candle.High = float64Value(FieldHigh)
+		candle.Low = float64Value(FieldLow)
+		candle.Close = float64Value(FieldClose)
+		candle.Volume = float64Value(FieldVolume)
+		if closeTime := int64Value(FieldCloseTime); closeTime != 0 {
+			candle.CloseTime = options.TimestampUnit.closeTimeToMillis(closeTime)
+		}
+		if isFinal, ok := values[columns[FieldIsFinal]].(bool); ok {
+			candle.IsFinal = isFinal
+		}
+		optionalFloat64Value := func(field string) *float64 {
+			if values[columns[field]] == nil {
+				return nil
+			}
+			converted := float64Value(field)
+			return &converted
+		}
+		candle.QuoteVolume = optionalFloat64Value(FieldQuoteVolume)
+		candle.TakerBuyBaseVolume = optionalFloat64Value(FieldTakerBuyBaseVolume)
+		candle.TakerBuyQuoteVolume = optionalFloat64Value(FieldTakerBuyQuoteVolume)
+		if values[columns[FieldTrades]] != nil {
+			trades := int64Value(FieldTrades)
+			candle.Trades = &trades
+		}
+		if convertErr != nil {
+			return nil, convertErr
+		}
+
+		if err := options.completeCandle(&candle, row); err != nil {
+			return nil, err
+		}
+		result = append(result, candle)
+	}
+
+	return result, nil
+}
+
+// optionalValue unwraps an optional field for a row map; nil becomes a parquet null.
+func optionalValue[T any](value *T) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func toInt64(value any) (int64, error) {
+	switch typed := value.(type) {
+	case int64:
+		return typed, nil
+	case int32:
+		return int64(typed), nil
+	case int:
+		return int64(typed), nil
+	case uint64:
+		return int64(typed), nil
+	case uint32:
+		return int64(typed), nil
+	case float64:
+		return int64(typed), nil
+	default:
+		return 0, fmt.Errorf("expected an integer, got %T", value)
+	}
+}
+
+func toFloat64(value any) (float64, error) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, nil
+	case float32:
+		return float64(typed), nil
+	case int64:
+		return float64(typed), nil
+	case int32:
+		return float64(typed), nil
+	default:
+		return 0, fmt.Errorf("expected a number, got %T", value)
+	}
+}
@@ -14,4 +14,25 @@ type Candle struct {
 
 	CloseTime int64 // optional, 0 if unknown
 	IsFinal   bool
+
+	// Order-flow fields from Binance kline columns 7-10. nil when the source does not report
+	// them or the row was stored before they were tracked.
+	QuoteVolume         *float64
+	Trades              *int64
+	TakerBuyBaseVolume  *float64
+	TakerBuyQuoteVolume *float64
+
+	// ResampledFrom is the source timeframe of a bar built by Resample, "" for exchange bars.
+	ResampledFrom string
+}
+
+// FinalOnly returns the closed candles of a series, preserving order.
+func FinalOnly(candleSeries []Candle) []Candle {
+	result := make([]Candle, 0, len(candleSeries))
+	for _, candle := range candleSeries {
+		if candle.IsFinal {
+			result = append(result, candle)
+		}
+	}
+	return result
 }
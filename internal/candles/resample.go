@@ -0,0 +1,142 @@
+package candles
+
+import "fmt"
+
+type ResampleResult struct {
+	Candles []Candle
+
+	// Open times of target bars that were not emitted because constituents were missing
+	SkippedBuckets []int64
+	// Open times of trailing bars still filling up, not emitted unless includePartial
+	PartialBuckets []int64
+}
+
+// Resample aggregates one ordered series into a higher timeframe: first open, max high, min low,
+// last close, summed volume (order-flow fields too, when every constituent has them), close_time
+// at the end of the target bar. A target bar is only emitted when every constituent is present
+// and final. With includePartial the trailing bar is also emitted, non-final, while it is still
+// filling up (a bar built from any non-final constituent is non-final too); such bars must not
+// replace final bars of the same series fetched from an exchange.
+func Resample(source []Candle, targetTimeframe string, includePartial bool) (ResampleResult, error) {
+	if len(source) == 0 {
+		return ResampleResult{}, nil
+	}
+
+	sourceTimeframe := source[0].Timeframe
+	sourceTF, err := ParseTimeframe(sourceTimeframe)
+	if err != nil {
+		return ResampleResult{}, err
+	}
+	targetTF, err := ParseTimeframe(targetTimeframe)
+	if err != nil {
+		return ResampleResult{}, err
+	}
+	if !nestsInto(sourceTF, targetTF) {
+		return ResampleResult{}, fmt.Errorf("cannot resample %s into %s (target bars must be larger and start on source bar boundaries)", sourceTimeframe, targetTimeframe)
+	}
+
+	var result ResampleResult
+	for start := 0; start < len(source); {
+		bucket := targetTF.Floor(source[start].Timestamp)
+
+		end := start
+		for end < len(source) && targetTF.Floor(source[end].Timestamp) == bucket {
+			if end > start && source[end].Timestamp <= source[end-1].Timestamp {
+				return ResampleResult{}, fmt.Errorf("source not strictly increasing at ts=%d", source[end].Timestamp)
+			}
+			if source[end].Timeframe != sourceTimeframe || source[end].Symbol != source[start].Symbol || source[end].Exchange != source[start].Exchange {
+				return ResampleResult{}, fmt.Errorf("source mixes series at ts=%d", source[end].Timestamp)
+			}
+			end++
+		}
+		constituents := source[start:end]
+		start = end
+
+		// Months and Monday weeks vary in how many source bars they hold, so count per bucket
+		constituentsPerBar, _ := sourceTF.BarsBetween(bucket, targetTF.Next(bucket))
+		isTrailing := end == len(source)
+		complete := int64(len(constituents)) == constituentsPerBar
+		if !complete && !(isTrailing && isContiguousFrom(constituents, bucket, sourceTF)) {
+			result.SkippedBuckets = append(result.SkippedBuckets, bucket)
+			continue
+		}
+
+		bar := Candle{
+			Exchange:  constituents[0].Exchange,
+			Symbol:    constituents[0].Symbol,
+			Timeframe: targetTF.String(),
+			Timestamp: bucket,
+			Open:      constituents[0].Open,
+			High:      constituents[0].High,
+			Low:       constituents[0].Low,
+			Close:     constituents[len(constituents)-1].Close,
+			CloseTime: targetTF.CloseTime(bucket),
+			IsFinal:   complete,
+
+			ResampledFrom: sourceTF.String(),
+		}
+		for _, candle := range constituents {
+			if candle.High > bar.High {
+				bar.High = candle.High
+			}
+			if candle.Low < bar.Low {
+				bar.Low = candle.Low
+			}
+			bar.Volume += candle.Volume
+			if !candle.IsFinal {
+				bar.IsFinal = false
+			}
+		}
+		bar.QuoteVolume = sumOptional(constituents, func(candle Candle) *float64 { return candle.QuoteVolume })
+		bar.Trades = sumOptional(constituents, func(candle Candle) *int64 { return candle.Trades })
+		bar.TakerBuyBaseVolume = sumOptional(constituents, func(candle Candle) *float64 { return candle.TakerBuyBaseVolume })
+		bar.TakerBuyQuoteVolume = sumOptional(constituents, func(candle Candle) *float64 { return candle.TakerBuyQuoteVolume })
+
+		if !bar.IsFinal && !includePartial {
+			result.PartialBuckets = append(result.PartialBuckets, bucket)
+			continue
+		}
+		result.Candles = append(result.Candles, bar)
+	}
+
+	return result, nil
+}
+
+// sumOptional totals an order-flow field, or returns nil if any constituent lacks it.
+func sumOptional[T int64 | float64](constituents []Candle, field func(Candle) *T) *T {
+	var total T
+	for _, candle := range constituents {
+		value := field(candle)
+		if value == nil {
+			return nil
+		}
+		total += *value
+	}
+	return &total
+}
+
+// isContiguousFrom reports whether constituents start at bucket and have no holes.
+func isContiguousFrom(constituents []Candle, bucket int64, sourceTF Timeframe) bool {
+	expected := bucket
+	for _, candle := range constituents {
+		if candle.Timestamp != expected {
+			return false
+		}
+		expected = sourceTF.Next(expected)
+	}
+	return true
+}
+
+// nestsInto reports whether every target bar is made of whole source bars: the source must be
+// a fixed epoch-aligned width that divides every target boundary (week offset, month start).
+func nestsInto(sourceTF Timeframe, targetTF Timeframe) bool {
+	if sourceTF.monthly || sourceTF.offsetMillis != 0 {
+		return false
+	}
+	if targetTF.monthly {
+		return dayMillis%sourceTF.widthMillis == 0
+	}
+	return targetTF.widthMillis > sourceTF.widthMillis &&
+		targetTF.widthMillis%sourceTF.widthMillis == 0 &&
+		targetTF.offsetMillis%sourceTF.widthMillis == 0
+}
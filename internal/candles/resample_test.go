@@ -0,0 +1,57 @@
+package candles
+
+import "testing"
+
+func hourlyCandles(start int64, count int) []Candle {
+	const hour = 60 * 60 * 1000
+	series := make([]Candle, count)
+	for i := range series {
+		open := start + int64(i)*hour
+		series[i] = Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Timeframe: "1h", Timestamp: open,
+			Open: 100, High: 101, Low: 99, Close: 100.5, Volume: 1,
+			CloseTime: open + hour - 1, IsFinal: true,
+		}
+	}
+	return series
+}
+
+func TestResampleTrailingPartialBar(t *testing.T) {
+	const start = 1709251200000 // 2024-03-01 00:00 UTC, a 4h boundary
+	source := hourlyCandles(start, 6)
+
+	result, err := Resample(source, "4h", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Candles) != 1 || !result.Candles[0].IsFinal {
+		t.Fatalf("default resample = %+v, want only the complete final bar", result.Candles)
+	}
+	if len(result.PartialBuckets) != 1 || result.PartialBuckets[0] != start+4*60*60*1000 {
+		t.Fatalf("partial buckets = %v, want the 04:00 bar", result.PartialBuckets)
+	}
+
+	result, err = Resample(source, "4h", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Candles) != 2 || !result.Candles[0].IsFinal || result.Candles[1].IsFinal {
+		t.Fatalf("resample with partial = %+v, want a final and a non-final bar", result.Candles)
+	}
+	if result.Candles[1].Volume != 2 {
+		t.Errorf("partial bar volume = %v, want 2", result.Candles[1].Volume)
+	}
+}
+
+func TestResampleNonFinalConstituentIsPartial(t *testing.T) {
+	source := hourlyCandles(1709251200000, 4)
+	source[3].IsFinal = false
+
+	result, err := Resample(source, "4h", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Candles) != 0 || len(result.PartialBuckets) != 1 {
+		t.Fatalf("resample = %+v, want the bar held back as partial", result)
+	}
+}
@@ -3,35 +3,138 @@ package candles
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
-func TimeframeToMillis(timeframe string) (int64, error) {
-	switch strings.ToLower(strings.TrimSpace(timeframe)) {
+const (
+	minuteMillis = 60 * 1000
+	hourMillis   = 60 * minuteMillis
+	dayMillis    = 24 * hourMillis
+	weekMillis   = 7 * dayMillis
+
+	// 1970-01-01 was a Thursday; Binance weeks open on Monday 00:00 UTC (1970-01-05).
+	mondayOffsetMillis = 4 * dayMillis
+)
+
+// Timeframe knows where bars of an interval open. Intraday and daily bars are fixed-width and
+// aligned to the Unix epoch; weekly bars are fixed-width but Monday-aligned; monthly bars
+// follow the calendar (1st of the month, 00:00 UTC), matching Binance kline boundaries.
+type Timeframe struct {
+	name string
+
+	widthMillis  int64 // 0 for monthly
+	offsetMillis int64
+	monthly      bool
+}
+
+// ParseTimeframe accepts Binance interval names. "1M" (month) is case-sensitive so it stays
+// distinct from "1m" (minute); other names are case-insensitive.
+func ParseTimeframe(value string) (Timeframe, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "1M" {
+		return Timeframe{name: "1M", monthly: true}, nil
+	}
+
+	name := strings.ToLower(trimmed)
+	switch name {
 	case "1m":
-		return 60 * 1000, nil
+		return fixedTimeframe(name, minuteMillis), nil
 	case "3m":
-		return 3 * 60 * 1000, nil
+		return fixedTimeframe(name, 3*minuteMillis), nil
 	case "5m":
-		return 5 * 60 * 1000, nil
+		return fixedTimeframe(name, 5*minuteMillis), nil
 	case "15m":
-		return 15 * 60 * 1000, nil
+		return fixedTimeframe(name, 15*minuteMillis), nil
 	case "30m":
-		return 30 * 60 * 1000, nil
+		return fixedTimeframe(name, 30*minuteMillis), nil
 	case "1h":
-		return 60 * 60 * 1000, nil
+		return fixedTimeframe(name, hourMillis), nil
 	case "2h":
-		return 2 * 60 * 60 * 1000, nil
+		return fixedTimeframe(name, 2*hourMillis), nil
 	case "4h":
-		return 4 * 60 * 60 * 1000, nil
+		return fixedTimeframe(name, 4*hourMillis), nil
 	case "6h":
-		return 6 * 60 * 60 * 1000, nil
+		return fixedTimeframe(name, 6*hourMillis), nil
 	case "8h":
-		return 8 * 60 * 60 * 1000, nil
+		return fixedTimeframe(name, 8*hourMillis), nil
 	case "12h":
-		return 12 * 60 * 60 * 1000, nil
+		return fixedTimeframe(name, 12*hourMillis), nil
 	case "1d":
-		return 24 * 60 * 60 * 1000, nil
+		return fixedTimeframe(name, dayMillis), nil
+	case "1w":
+		return Timeframe{name: name, widthMillis: weekMillis, offsetMillis: mondayOffsetMillis}, nil
 	default:
-		return 0, fmt.Errorf("unsupported timeframe: %q", timeframe)
+		return Timeframe{}, fmt.Errorf("unsupported timeframe: %q", value)
+	}
+}
+
+func fixedTimeframe(name string, widthMillis int64) Timeframe {
+	return Timeframe{name: name, widthMillis: widthMillis}
+}
+
+func (timeframe Timeframe) String() string {
+	return timeframe.name
+}
+
+// NominalMillis is the typical bar width (30 days for a month), for sizing ranges only.
+func (timeframe Timeframe) NominalMillis() int64 {
+	if timeframe.monthly {
+		return 30 * dayMillis
+	}
+	return timeframe.widthMillis
+}
+
+// Floor returns the open time of the bar containing ts.
+func (timeframe Timeframe) Floor(ts int64) int64 {
+	if timeframe.monthly {
+		t := time.UnixMilli(ts).UTC()
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	}
+	shifted := ts - timeframe.offsetMillis
+	remainder := shifted % timeframe.widthMillis
+	if remainder < 0 {
+		remainder += timeframe.widthMillis
+	}
+	return ts - remainder
+}
+
+// Next returns the open time of the bar after the one containing ts.
+func (timeframe Timeframe) Next(ts int64) int64 {
+	openTime := timeframe.Floor(ts)
+	if timeframe.monthly {
+		return time.UnixMilli(openTime).UTC().AddDate(0, 1, 0).UnixMilli()
+	}
+	return openTime + timeframe.widthMillis
+}
+
+// Previous returns the open time of the bar before the one containing ts.
+func (timeframe Timeframe) Previous(ts int64) int64 {
+	openTime := timeframe.Floor(ts)
+	if timeframe.monthly {
+		return time.UnixMilli(openTime).UTC().AddDate(0, -1, 0).UnixMilli()
+	}
+	return openTime - timeframe.widthMillis
+}
+
+// CloseTime is the Binance close_time of the bar opening at openTime (last millisecond of the bar).
+func (timeframe Timeframe) CloseTime(openTime int64) int64 {
+	return timeframe.Next(openTime) - 1
+}
+
+func (timeframe Timeframe) IsAligned(ts int64) bool {
+	return timeframe.Floor(ts) == ts
+}
+
+// BarsBetween counts bar steps from the bar opening at from to the bar opening at to
+// (0 when equal). ok is false if either time is not a bar boundary or to < from.
+func (timeframe Timeframe) BarsBetween(from int64, to int64) (count int64, ok bool) {
+	if !timeframe.IsAligned(from) || !timeframe.IsAligned(to) || to < from {
+		return 0, false
+	}
+	if timeframe.monthly {
+		fromTime := time.UnixMilli(from).UTC()
+		toTime := time.UnixMilli(to).UTC()
+		return int64(toTime.Year()-fromTime.Year())*12 + int64(toTime.Month()-fromTime.Month()), true
 	}
+	return (to - from) / timeframe.widthMillis, true
 }
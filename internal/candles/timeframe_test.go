@@ -0,0 +1,110 @@
+package candles
+
+import (
+	"testing"
+	"time"
+)
+
+func at(year int, month time.Month, day int, hour int, minute int) int64 {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC).UnixMilli()
+}
+
+func TestParseTimeframeNormalizes(t *testing.T) {
+	tests := []struct{ input, want string }{
+		{"4h", "4h"}, {"4H", "4h"}, {" 1D ", "1d"}, {"1W", "1w"}, {"1m", "1m"}, {"1M", "1M"},
+	}
+	for _, test := range tests {
+		timeframe, err := ParseTimeframe(test.input)
+		if err != nil {
+			t.Fatalf("ParseTimeframe(%q): %v", test.input, err)
+		}
+		if timeframe.String() != test.want {
+			t.Errorf("ParseTimeframe(%q) = %s, want %s", test.input, timeframe, test.want)
+		}
+	}
+	for _, input := range []string{"", "4hr", "2d", "1y"} {
+		if _, err := ParseTimeframe(input); err == nil {
+			t.Errorf("ParseTimeframe(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestTimeframeCalendarBoundaries(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeframe string
+		ts        int64
+		floor     int64
+		next      int64
+		previous  int64
+	}{
+		// 2023-12-31 is a Sunday: its week opened on Monday 2023-12-25 and the next spans the new year
+		{"week across new year", "1w", at(2023, time.December, 31, 23, 59), at(2023, time.December, 25, 0, 0), at(2024, time.January, 1, 0, 0), at(2023, time.December, 18, 0, 0)},
+		{"week on its monday", "1w", at(2024, time.January, 1, 0, 0), at(2024, time.January, 1, 0, 0), at(2024, time.January, 8, 0, 0), at(2023, time.December, 25, 0, 0)},
+		{"week with feb 29", "1w", at(2024, time.February, 29, 12, 0), at(2024, time.February, 26, 0, 0), at(2024, time.March, 4, 0, 0), at(2024, time.February, 19, 0, 0)},
+		{"week before the epoch monday", "1w", at(1970, time.January, 2, 0, 0), at(1969, time.December, 29, 0, 0), at(1970, time.January, 5, 0, 0), at(1969, time.December, 22, 0, 0)},
+
+		{"month across new year", "1M", at(2023, time.December, 31, 23, 59), at(2023, time.December, 1, 0, 0), at(2024, time.January, 1, 0, 0), at(2023, time.November, 1, 0, 0)},
+		{"january after the new year", "1M", at(2024, time.January, 15, 0, 0), at(2024, time.January, 1, 0, 0), at(2024, time.February, 1, 0, 0), at(2023, time.December, 1, 0, 0)},
+		{"leap february", "1M", at(2024, time.February, 29, 23, 59), at(2024, time.February, 1, 0, 0), at(2024, time.March, 1, 0, 0), at(2024, time.January, 1, 0, 0)},
+		{"common february", "1M", at(2023, time.February, 28, 12, 0), at(2023, time.February, 1, 0, 0), at(2023, time.March, 1, 0, 0), at(2023, time.January, 1, 0, 0)},
+		{"30-day month", "1M", at(2024, time.April, 30, 0, 0), at(2024, time.April, 1, 0, 0), at(2024, time.May, 1, 0, 0), at(2024, time.March, 1, 0, 0)},
+		{"march after leap february", "1M", at(2024, time.March, 31, 0, 0), at(2024, time.March, 1, 0, 0), at(2024, time.April, 1, 0, 0), at(2024, time.February, 1, 0, 0)},
+
+		{"day on feb 29", "1d", at(2024, time.February, 29, 13, 0), at(2024, time.February, 29, 0, 0), at(2024, time.March, 1, 0, 0), at(2024, time.February, 28, 0, 0)},
+		{"4h across new year", "4h", at(2023, time.December, 31, 22, 0), at(2023, time.December, 31, 20, 0), at(2024, time.January, 1, 0, 0), at(2023, time.December, 31, 16, 0)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			timeframe, err := ParseTimeframe(test.timeframe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := timeframe.Floor(test.ts); got != test.floor {
+				t.Errorf("Floor = %s, want %s", time.UnixMilli(got).UTC(), time.UnixMilli(test.floor).UTC())
+			}
+			if got := timeframe.Next(test.ts); got != test.next {
+				t.Errorf("Next = %s, want %s", time.UnixMilli(got).UTC(), time.UnixMilli(test.next).UTC())
+			}
+			if got := timeframe.Previous(test.ts); got != test.previous {
+				t.Errorf("Previous = %s, want %s", time.UnixMilli(got).UTC(), time.UnixMilli(test.previous).UTC())
+			}
+			if got := timeframe.CloseTime(test.floor); got != test.next-1 {
+				t.Errorf("CloseTime = %d, want %d", got, test.next-1)
+			}
+			if timeframe.IsAligned(test.ts) != (test.ts == test.floor) {
+				t.Errorf("IsAligned = %v, want %v", timeframe.IsAligned(test.ts), test.ts == test.floor)
+			}
+		})
+	}
+}
+
+func TestTimeframeBarsBetween(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeframe string
+		from, to  int64
+		want      int64
+		ok        bool
+	}{
+		{"months across new year", "1M", at(2023, time.November, 1, 0, 0), at(2024, time.March, 1, 0, 0), 4, true},
+		{"same month", "1M", at(2024, time.February, 1, 0, 0), at(2024, time.February, 1, 0, 0), 0, true},
+		{"weeks across new year", "1w", at(2023, time.December, 25, 0, 0), at(2024, time.January, 15, 0, 0), 3, true},
+		{"days over feb 29", "1d", at(2024, time.February, 28, 0, 0), at(2024, time.March, 1, 0, 0), 2, true},
+		{"unaligned month", "1M", at(2024, time.February, 2, 0, 0), at(2024, time.March, 1, 0, 0), 0, false},
+		{"sunday is not a week boundary", "1w", at(2023, time.December, 31, 0, 0), at(2024, time.January, 8, 0, 0), 0, false},
+		{"backwards", "4h", at(2024, time.January, 1, 4, 0), at(2024, time.January, 1, 0, 0), 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			timeframe, err := ParseTimeframe(test.timeframe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := timeframe.BarsBetween(test.from, test.to)
+			if got != test.want || ok != test.ok {
+				t.Errorf("BarsBetween = %d, %v; want %d, %v", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io"
 	"math"
 	"sort"
 
@@ -26,16 +27,16 @@ type confidenceRow struct {
 }
 
 type confidenceStats struct {
-	Threshold float64
+	Threshold float64 `json:"threshold"`
 
-	TotalPredictions int
-	Trades           int
-	Coverage         float64
+	TotalPredictions int     `json:"total_predictions"`
+	Trades           int     `json:"trades"`
+	Coverage         float64 `json:"coverage"`
 
-	DirectionalPrecision float64 // among trades only
-	DirectionalRecall    float64 // among actual UP/DOWN only (optional-ish)
+	DirectionalPrecision float64 `json:"directional_precision"` // among trades only
+	DirectionalRecall    float64 `json:"directional_recall"`    // among actual UP/DOWN only (optional-ish)
 
-	AverageForwardReturn float64 // among trades (if joined)
+	AverageForwardReturn float64 `json:"average_forward_return"` // among trades (if joined)
 }
 
 var confidenceSymbol string
@@ -46,8 +47,9 @@ var confidenceMaxThreshold float64
 var confidenceStep float64
 
 var confidenceCommand = &cobra.Command{
-	Use:   "confidence",
-	Short: "Analyze prediction confidence thresholds (coverage/precision/returns) from stored predictions",
+	Use:         "confidence",
+	Short:       "Analyze prediction confidence thresholds (coverage/precision/returns) from stored predictions",
+	Annotations: reportOutput,
 	RunE: func(command *cobra.Command, args []string) error {
 		ctx := context.Background()
 
@@ -70,7 +72,7 @@ var confidenceCommand = &cobra.Command{
 			return fmt.Errorf("--min must be <= --max")
 		}
 
-		rows, err := loadConfidenceRows(ctx, db, "binance", confidenceSymbol, confidenceTimeframe, confidenceModelName)
+		rows, err := loadConfidenceRows(ctx, db, exchangeName, confidenceSymbol, confidenceTimeframe, confidenceModelName)
 		if err != nil {
 			return err
 		}
@@ -81,50 +83,88 @@ var confidenceCommand = &cobra.Command{
 		thresholds := buildThresholds(confidenceMinThreshold, confidenceMaxThreshold, confidenceStep)
 		stats := computeConfidenceStats(rows, thresholds)
 
-		fmt.Println("db:", databasePath)
-		fmt.Println("exchange: binance")
-		fmt.Println("symbol:", confidenceSymbol)
-		fmt.Println("timeframe:", confidenceTimeframe)
-		fmt.Println("model:", confidenceModelName)
-		fmt.Println("predictions:", len(rows))
-		fmt.Println()
-
-		// Print a readable table
-		fmt.Printf("%-8s %-10s %-10s %-12s %-14s %-14s %-14s\n",
-			"thr",
-			"trades",
-			"coverage",
-			"dir_prec",
-			"dir_recall",
-			"avg_fwd_ret",
-			"notes",
-		)
+		return writeReport(confidenceReport{
+			DB:          databasePath,
+			Exchange:    exchangeName,
+			Symbol:      confidenceSymbol,
+			Timeframe:   confidenceTimeframe,
+			Model:       confidenceModelName,
+			Predictions: len(rows),
+			Thresholds:  stats,
+		})
+	},
+}
 
-		for _, s := range stats {
-			note := ""
-			if s.Trades < 50 {
-				note = "low-n"
-			}
-			fmt.Printf("%-8.2f %-10d %-10.3f %-12.3f %-14.3f %-14.5f %-14s\n",
-				s.Threshold,
-				s.Trades,
-				s.Coverage,
-				s.DirectionalPrecision,
-				s.DirectionalRecall,
-				s.AverageForwardReturn,
-				note,
-			)
+type confidenceReport struct {
+	DB        string `json:"db"`
+	Exchange  string `json:"exchange"`
+	Symbol    string `json:"symbol"`
+	Timeframe string `json:"timeframe"`
+	Model     string `json:"model"`
+
+	Predictions int               `json:"predictions"`
+	Thresholds  []confidenceStats `json:"thresholds"`
+}
+
+func (report confidenceReport) writeText(writer io.Writer) {
+	fmt.Fprintln(writer, "db:", report.DB)
+	fmt.Fprintln(writer, "exchange:", report.Exchange)
+	fmt.Fprintln(writer, "symbol:", report.Symbol)
+	fmt.Fprintln(writer, "timeframe:", report.Timeframe)
+	fmt.Fprintln(writer, "model:", report.Model)
+	fmt.Fprintln(writer, "predictions:", report.Predictions)
+	fmt.Fprintln(writer)
+
+	// Print a readable table
+	fmt.Fprintf(writer, "%-8s %-10s %-10s %-12s %-14s %-14s %-14s\n",
+		"thr",
+		"trades",
+		"coverage",
+		"dir_prec",
+		"dir_recall",
+		"avg_fwd_ret",
+		"notes",
+	)
+
+	for _, s := range report.Thresholds {
+		note := ""
+		if s.Trades < 50 {
+			note = "low-n"
 		}
+		fmt.Fprintf(writer, "%-8.2f %-10d %-10.3f %-12.3f %-14.3f %-14.5f %-14s\n",
+			s.Threshold,
+			s.Trades,
+			s.Coverage,
+			s.DirectionalPrecision,
+			s.DirectionalRecall,
+			s.AverageForwardReturn,
+			note,
+		)
+	}
 
-		fmt.Println()
-		fmt.Println("Interpretation tips:")
-		fmt.Println("- coverage = trades / predictions (higher threshold => fewer trades)")
-		fmt.Println("- dir_prec = P(actual matches direction | traded)")
-		fmt.Println("- avg_fwd_ret uses label forward return (simple 4H horizon proxy, not full trading sim)")
-		fmt.Println("- Focus on thresholds where trades are not 'low-n' (e.g. >= 50).")
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "Interpretation tips:")
+	fmt.Fprintln(writer, "- coverage = trades / predictions (higher threshold => fewer trades)")
+	fmt.Fprintln(writer, "- dir_prec = P(actual matches direction | traded)")
+	fmt.Fprintln(writer, "- avg_fwd_ret uses label forward return (simple 4H horizon proxy, not full trading sim)")
+	fmt.Fprintln(writer, "- Focus on thresholds where trades are not 'low-n' (e.g. >= 50).")
+}
 
-		return nil
-	},
+func (report confidenceReport) csvTable() ([]string, [][]string) {
+	header := []string{"threshold", "total_predictions", "trades", "coverage", "directional_precision", "directional_recall", "average_forward_return"}
+	records := make([][]string, 0, len(report.Thresholds))
+	for _, s := range report.Thresholds {
+		records = append(records, []string{
+			csvFloat(s.Threshold),
+			csvInt(s.TotalPredictions),
+			csvInt(s.Trades),
+			csvFloat(s.Coverage),
+			csvFloat(s.DirectionalPrecision),
+			csvFloat(s.DirectionalRecall),
+			csvFloat(s.AverageForwardReturn),
+		})
+	}
+	return header, records
 }
 
 func init() {
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"btc-4h-prediction-model/internal/candlefile"
+	"btc-4h-prediction-model/internal/store"
+)
+
+var exportCommand = &cobra.Command{
+	Use:   "export",
+	Short: "Export data from the database to files",
+}
+
+var exportCandlesSymbol string
+var exportCandlesTimeframe string
+var exportCandlesOut string
+var exportCandlesFormat string
+var exportCandlesColumns string
+var exportCandlesTimestampUnit string
+
+var exportCandlesCommand = &cobra.Command{
+	Use:   "candles",
+	Short: "Export candles to CSV or Parquet (a still-forming bar keeps is_final=0)",
+	RunE: func(command *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if exportCandlesOut == "" {
+			return fmt.Errorf("--out is required")
+		}
+		format, err := candlefile.ResolveFormat(exportCandlesFormat, exportCandlesOut)
+		if err != nil {
+			return err
+		}
+		options, err := candleFileOptions(exportCandlesColumns, exportCandlesTimestampUnit, exportCandlesSymbol, exportCandlesTimeframe)
+		if err != nil {
+			return err
+		}
+
+		db, err := store.OpenSQLite(databasePath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		candleSeries, err := store.LoadAllCandlesOrdered(ctx, db, exchangeName, exportCandlesSymbol, exportCandlesTimeframe)
+		if err != nil {
+			return err
+		}
+		if len(candleSeries) == 0 {
+			return fmt.Errorf("no candles found for %s %s", exportCandlesSymbol, exportCandlesTimeframe)
+		}
+
+		if err := candlefile.WriteFile(exportCandlesOut, format, candleSeries, options); err != nil {
+			return err
+		}
+
+		fmt.Println("db:", databasePath)
+		fmt.Println("exchange:", exchangeName)
+		fmt.Println("symbol:", exportCandlesSymbol)
+		fmt.Println("timeframe:", exportCandlesTimeframe)
+		fmt.Println("format:", format)
+		fmt.Println("out:", exportCandlesOut)
+		fmt.Println("exported:", len(candleSeries))
+		return nil
+	},
+}
+
+func init() {
+	exportCandlesCommand.Flags().StringVar(&exportCandlesSymbol, "symbol", "BTCUSDT", "Symbol (e.g. BTCUSDT)")
+	exportCandlesCommand.Flags().StringVar(&exportCandlesTimeframe, "timeframe", "4h", "Timeframe (e.g. 4h)")
+	exportCandlesCommand.Flags().StringVar(&exportCandlesOut, "out", "", "Output file path")
+	exportCandlesCommand.Flags().StringVar(&exportCandlesFormat, "format", "", "csv or parquet (default: from --out extension)")
+	exportCandlesCommand.Flags().StringVar(&exportCandlesColumns, "columns", "", "Column renames as field=column pairs (e.g. timestamp=open_time,volume=vol)")
+	exportCandlesCommand.Flags().StringVar(&exportCandlesTimestampUnit, "timestamp-unit", "ms", "Unit for timestamp/close_time columns: s, ms, us or ns")
+
+	exportCommand.AddCommand(exportCandlesCommand)
+}
+
+func candleFileOptions(columns string, timestampUnit string, symbol string, timeframe string) (candlefile.Options, error) {
+	mapping, err := candlefile.ParseColumnMapping(columns)
+	if err != nil {
+		return candlefile.Options{}, err
+	}
+	unit, err := candlefile.ParseTimestampUnit(timestampUnit)
+	if err != nil {
+		return candlefile.Options{}, err
+	}
+	return candlefile.Options{
+		Columns:       mapping,
+		TimestampUnit: unit,
+		Exchange:      exchangeName,
+		Symbol:        symbol,
+		Timeframe:     timeframe,
+	}, nil
+}
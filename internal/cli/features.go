@@ -2,20 +2,31 @@ package cli
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"btc-4h-prediction-model/internal/candles"
 	"btc-4h-prediction-model/internal/features"
 	"btc-4h-prediction-model/internal/store"
 )
 
 var featuresSymbol string
 var featuresTimeframe string
+var featuresFuturesExchange string
+var featuresFuturesSymbol string
+var featuresOpenInterestPeriod string
+var featuresRegistry []string
+var featuresFull bool
 
 var featuresCommand = &cobra.Command{
-	Use:   "features",
-	Short: "Compute features from candles and store them in the database",
+	Use:         "features",
+	Short:       "Compute features from candles and store them in the database",
+	Annotations: reportOutput,
 	RunE: func(command *cobra.Command, args []string) error {
 		ctx := context.Background()
 
@@ -25,33 +36,231 @@ var featuresCommand = &cobra.Command{
 		}
 		defer db.Close()
 
-		candleSeries, err := store.LoadCandlesOrdered(ctx, db, "binance", featuresSymbol, featuresTimeframe)
+		specs, err := features.ParseSpecs(featuresRegistry)
 		if err != nil {
 			return err
 		}
-		if len(candleSeries) == 0 {
-			return fmt.Errorf("no candles found for %s %s", featuresSymbol, featuresTimeframe)
-		}
 
-		featureRows, err := features.BuildFeaturesFromCandles(candleSeries)
+		report, err := runFeatures(ctx, db, featuresOptions{
+			DB:                 databasePath,
+			Exchange:           exchangeName,
+			Symbol:             featuresSymbol,
+			Timeframe:          featuresTimeframe,
+			FuturesExchange:    featuresFuturesExchange,
+			FuturesSymbol:      featuresFuturesSymbol,
+			OpenInterestPeriod: featuresOpenInterestPeriod,
+			Specs:              specs,
+			Full:               featuresFull,
+		})
 		if err != nil {
 			return err
 		}
+		return writeReport(report)
+	},
+}
 
-		if err := store.UpsertFeatures(ctx, db, featureRows); err != nil {
-			return err
+// featuresOptions are the features flags; quant run fills them from the pipeline config.
+type featuresOptions struct {
+	DB                 string // database path, shown in the report
+	Exchange           string
+	Symbol             string
+	Timeframe          string
+	FuturesExchange    string
+	FuturesSymbol      string // empty = Symbol
+	OpenInterestPeriod string // empty = Timeframe
+	Specs              []features.Spec
+	Full               bool // ignore feature_state and rebuild every row
+}
+
+func runFeatures(ctx context.Context, db *sql.DB, options featuresOptions) (featuresReport, error) {
+	timeframe, err := candles.ParseTimeframe(options.Timeframe)
+	if err != nil {
+		return featuresReport{}, err
+	}
+	options.Timeframe = timeframe.String()
+
+	var saved []features.StreamState
+	if !options.Full {
+		if saved, err = store.LoadFeatureStates(ctx, db, options.Exchange, options.Symbol, options.Timeframe); err != nil {
+			return featuresReport{}, err
 		}
+	}
 
-		fmt.Println("db:", databasePath)
-		fmt.Println("exchange: binance")
-		fmt.Println("symbol:", featuresSymbol)
-		fmt.Println("timeframe:", featuresTimeframe)
-		fmt.Println("features rows upserted:", len(featureRows))
-		return nil
-	},
+	futuresSymbol := options.FuturesSymbol
+	if futuresSymbol == "" {
+		futuresSymbol = options.Symbol
+	}
+	openInterestPeriod := options.OpenInterestPeriod
+	if openInterestPeriod == "" {
+		openInterestPeriod = options.Timeframe
+	}
+	fundingRates, err := store.LoadFundingRatesOrdered(ctx, db, options.FuturesExchange, futuresSymbol)
+	if err != nil {
+		return featuresReport{}, err
+	}
+	openInterest, err := store.LoadOpenInterestOrdered(ctx, db, options.FuturesExchange, futuresSymbol, openInterestPeriod)
+	if err != nil {
+		return featuresReport{}, err
+	}
+
+	result, err := features.RunIncremental(ctx, candleHistory{db: db, exchange: options.Exchange, symbol: options.Symbol}, features.IncrementalRun{
+		Exchange:           options.Exchange,
+		Symbol:             options.Symbol,
+		Timeframe:          options.Timeframe,
+		Specs:              options.Specs,
+		Saved:              saved,
+		FuturesExchange:    options.FuturesExchange,
+		FuturesSymbol:      futuresSymbol,
+		OpenInterestPeriod: openInterestPeriod,
+		FundingRates:       fundingRates,
+		OpenInterest:       openInterest,
+	})
+	if err != nil {
+		return featuresReport{}, err
+	}
+
+	// Streams that started over replace what they stored, so bars they no longer fill go away
+	if result.RowsRestarted {
+		err = store.ReplaceFeatures(ctx, db, options.Exchange, options.Symbol, options.Timeframe, result.Rows)
+	} else {
+		err = store.UpsertFeatures(ctx, db, result.Rows)
+	}
+	if err != nil {
+		return featuresReport{}, err
+	}
+	if err := store.UpdateFeatureDerivatives(ctx, db, result.Rejoined); err != nil {
+		return featuresReport{}, err
+	}
+	if err := store.ReplaceFeatureValues(ctx, db, options.Exchange, options.Symbol, options.Timeframe, result.ValuesRestarted, result.Values); err != nil {
+		return featuresReport{}, err
+	}
+	// Save every stream's state only after its rows are stored
+	updatedAt := time.Now().Unix()
+	for i := range result.States {
+		result.States[i].UpdatedAt = updatedAt
+	}
+	if err := store.UpsertFeatureStates(ctx, db, result.States); err != nil {
+		return featuresReport{}, err
+	}
+
+	mode := "incremental"
+	if options.Full {
+		mode = "full"
+	}
+	return featuresReport{
+		DB:                 options.DB,
+		Exchange:           options.Exchange,
+		Symbol:             options.Symbol,
+		Timeframe:          options.Timeframe,
+		Mode:               mode,
+		StreamsResumed:     result.Resumed,
+		Streams:            result.Streams,
+		FundingRatesJoined: len(fundingRates),
+		OpenInterestJoined: len(openInterest),
+		RowsUpserted:       len(result.Rows),
+		RowsRejoined:       len(result.Rejoined),
+		RegistryFeatures:   specStrings(options.Specs),
+		ValuesUpserted:     len(result.Values),
+	}, nil
+}
+
+// candleHistory is the stored candle history of one exchange and symbol, read by incremental
+// feature runs.
+type candleHistory struct {
+	db       *sql.DB
+	exchange string
+	symbol   string
+}
+
+func (history candleHistory) CandlesSince(ctx context.Context, timeframe string, from int64) ([]candles.Candle, error) {
+	return store.LoadCandlesSince(ctx, history.db, history.exchange, history.symbol, timeframe, from)
+}
+
+func (history candleHistory) CountCandlesThrough(ctx context.Context, timeframe string, through int64) (int, error) {
+	return store.CountFinalCandlesThrough(ctx, history.db, history.exchange, history.symbol, timeframe, through)
+}
+
+func (history candleHistory) LatestCandleBefore(ctx context.Context, timeframe string, before int64) (int64, bool, error) {
+	return store.LatestFinalCandleTimestampBefore(ctx, history.db, history.exchange, history.symbol, timeframe, before)
+}
+
+type featuresReport struct {
+	DB        string `json:"db"`
+	Exchange  string `json:"exchange"`
+	Symbol    string `json:"symbol"`
+	Timeframe string `json:"timeframe"`
+
+	Mode           string `json:"mode"` // incremental or full
+	StreamsResumed int    `json:"streams_resumed"`
+	Streams        int    `json:"streams"` // fixed columns + registry specs
+
+	FundingRatesJoined int `json:"funding_rates_joined"`
+	OpenInterestJoined int `json:"open_interest_joined"`
+	RowsUpserted       int `json:"rows_upserted"`
+	RowsRejoined       int `json:"rows_rejoined"` // earlier rows whose funding/oi_chg changed
+
+	RegistryFeatures []string `json:"registry_features"`
+	ValuesUpserted   int      `json:"feature_values_upserted"`
+}
+
+func (report featuresReport) writeText(writer io.Writer) {
+	fmt.Fprintln(writer, "db:", report.DB)
+	fmt.Fprintln(writer, "exchange:", report.Exchange)
+	fmt.Fprintln(writer, "symbol:", report.Symbol)
+	fmt.Fprintln(writer, "timeframe:", report.Timeframe)
+	fmt.Fprintf(writer, "mode: %s (%d/%d streams resumed)\n", report.Mode, report.StreamsResumed, report.Streams)
+	fmt.Fprintln(writer, "funding rates joined:", report.FundingRatesJoined)
+	fmt.Fprintln(writer, "open interest samples joined:", report.OpenInterestJoined)
+	fmt.Fprintln(writer, "features rows upserted:", report.RowsUpserted)
+	if report.RowsRejoined > 0 {
+		fmt.Fprintln(writer, "earlier rows re-joined with new funding/open interest:", report.RowsRejoined)
+	}
+	if len(report.RegistryFeatures) > 0 {
+		fmt.Fprintln(writer, "registry features:", strings.Join(report.RegistryFeatures, " "))
+		fmt.Fprintln(writer, "feature values upserted:", report.ValuesUpserted)
+	}
+}
+
+func (report featuresReport) csvTable() ([]string, [][]string) {
+	return singleRowTable(
+		csvField{"exchange", report.Exchange},
+		csvField{"symbol", report.Symbol},
+		csvField{"timeframe", report.Timeframe},
+		csvField{"mode", report.Mode},
+		csvField{"streams_resumed", csvInt(report.StreamsResumed)},
+		csvField{"streams", csvInt(report.Streams)},
+		csvField{"funding_rates_joined", csvInt(report.FundingRatesJoined)},
+		csvField{"open_interest_joined", csvInt(report.OpenInterestJoined)},
+		csvField{"rows_upserted", csvInt(report.RowsUpserted)},
+		csvField{"rows_rejoined", csvInt(report.RowsRejoined)},
+		csvField{"registry_features", strings.Join(report.RegistryFeatures, " ")},
+		csvField{"feature_values_upserted", csvInt(report.ValuesUpserted)},
+	)
 }
 
 func init() {
 	featuresCommand.Flags().StringVar(&featuresSymbol, "symbol", "BTCUSDT", "Symbol (e.g. BTCUSDT)")
 	featuresCommand.Flags().StringVar(&featuresTimeframe, "timeframe", "4h", "Timeframe (e.g. 4h)")
+	featuresCommand.Flags().StringVar(&featuresFuturesExchange, "futures-exchange", "binance_usdm", "Exchange whose funding/open interest history is joined (stored by quant futures)")
+	featuresCommand.Flags().StringVar(&featuresFuturesSymbol, "futures-symbol", "", "Perpetual symbol to join (default: --symbol)")
+	featuresCommand.Flags().StringVar(&featuresOpenInterestPeriod, "oi-period", "", "Open interest period to join (default: --timeframe)")
+	featuresCommand.Flags().BoolVar(&featuresFull, "full", false, "Ignore the saved feature state and rebuild every row (default: only process candles added since the last run)")
+	featuresCommand.Flags().StringArrayVar(&featuresRegistry, "feature", nil, "Registry feature to compute into feature_values, e.g. ema(20), or 1d:ema(20) from another stored timeframe (repeatable; "+registryUsage()+")")
+}
+
+// registryUsage lists the registered feature specs for flag help.
+func registryUsage() string {
+	var usages []string
+	for _, definition := range features.Definitions() {
+		usages = append(usages, definition.Usage())
+	}
+	return "available: " + strings.Join(usages, ", ")
+}
+
+func specStrings(specs []features.Spec) []string {
+	result := make([]string, len(specs))
+	for i, spec := range specs {
+		result[i] = spec.String()
+	}
+	return result
 }
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/features"
+	"btc-4h-prediction-model/internal/store"
+)
+
+func TestRunFeaturesDropsValuesOfRemovedBars(t *testing.T) {
+	ctx := context.Background()
+	const start = 1709251200000
+	db := openTestDatabase(t)
+	var series []candles.Candle
+	for i := int64(0); i < 30; i++ {
+		series = append(series, testCandle(start+i*fourHourMillis))
+	}
+	if err := store.UpsertCandles(ctx, db, series); err != nil {
+		t.Fatal(err)
+	}
+	specs, err := features.ParseSpecs([]string{"ema(5)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := featuresOptions{Exchange: "test", Symbol: "BTCUSDT", Timeframe: "4h", FuturesExchange: "binance_usdm", Specs: specs}
+	if _, err := runFeatures(ctx, db, options); err != nil {
+		t.Fatal(err)
+	}
+
+	// A re-ingest that no longer has bars 20-21: the streams restart and must not keep them
+	removed := []int64{start + 20*fourHourMillis, start + 21*fourHourMillis}
+	if _, err := db.Exec(`DELETE FROM candles WHERE timestamp IN (?, ?);`, removed[0], removed[1]); err != nil {
+		t.Fatal(err)
+	}
+	report, err := runFeatures(ctx, db, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.StreamsResumed != 0 {
+		t.Fatalf("resumed %d streams over edited history, want 0", report.StreamsResumed)
+	}
+
+	values, err := store.LoadFeatureValues(ctx, db, "test", "BTCUSDT", "4h", specs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM features WHERE exchange='test';`).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	for _, timestamp := range removed {
+		if _, ok := values[timestamp]; ok {
+			t.Errorf("ema(5) still stored for removed bar %d", timestamp)
+		}
+	}
+	if rows != len(series)-len(removed) {
+		t.Errorf("%d feature rows stored, want %d", rows, len(series)-len(removed))
+	}
+}
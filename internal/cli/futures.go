@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"btc-4h-prediction-model/internal/exchange"
+	"btc-4h-prediction-model/internal/store"
+)
+
+var futuresSymbol string
+var futuresDays int
+var futuresOpenInterestPeriod string
+var futuresBaseURL string
+
+var futuresCommand = &cobra.Command{
+	Use:   "futures",
+	Short: "Fetch perpetual funding rate and open interest history (use --exchange binance_usdm)",
+	RunE: func(command *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		market, ok := exchange.BinanceMarketByName(exchangeName)
+		if !ok || market.FundingRatePath == "" {
+			return fmt.Errorf("exchange %q has no futures history (try --exchange %s)", exchangeName, exchange.BinanceUSDMFutures.Name)
+		}
+		if futuresDays <= 0 {
+			return fmt.Errorf("--days must be > 0")
+		}
+
+		database, err := store.OpenSQLite(databasePath)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		client := exchange.NewBinanceMarketClient(market, 0)
+		client.BaseURL = futuresBaseURL
+
+		now := time.Now().UTC()
+		startTimeMillis := now.Add(time.Duration(-futuresDays) * 24 * time.Hour).UnixMilli()
+
+		fundingRates, err := client.FetchFundingRatesPaginated(ctx, futuresSymbol, startTimeMillis, now.UnixMilli())
+		if err != nil {
+			return err
+		}
+		if err := store.UpsertFundingRates(ctx, database, fundingRates); err != nil {
+			return err
+		}
+
+		openInterestSamples := 0
+		if market.OpenInterestPath != "" && futuresOpenInterestPeriod != "" {
+			openInterest, err := client.FetchOpenInterestPaginated(ctx, futuresSymbol, futuresOpenInterestPeriod, startTimeMillis, now.UnixMilli())
+			if err != nil {
+				return err
+			}
+			if err := store.UpsertOpenInterest(ctx, database, openInterest); err != nil {
+				return err
+			}
+			openInterestSamples = len(openInterest)
+		}
+
+		fmt.Println("db:", databasePath)
+		fmt.Println("exchange:", market.Name)
+		fmt.Println("symbol:", futuresSymbol)
+		fmt.Println("days:", futuresDays)
+		fmt.Println("funding rates upserted:", len(fundingRates))
+		if market.OpenInterestPath == "" {
+			fmt.Println("open interest: not available on", market.Name)
+		} else {
+			fmt.Println("open interest period:", futuresOpenInterestPeriod)
+			fmt.Println("open interest upserted:", openInterestSamples)
+		}
+		return nil
+	},
+}
+
+func init() {
+	futuresCommand.Flags().StringVar(&futuresSymbol, "symbol", "BTCUSDT", "Perpetual contract symbol (e.g. BTCUSDT)")
+	futuresCommand.Flags().IntVar(&futuresDays, "days", 30, "Lookback window in days (Binance keeps about 30 days of open interest)")
+	futuresCommand.Flags().StringVar(&futuresOpenInterestPeriod, "oi-period", "4h", "Open interest sample period (5m..1d, empty skips open interest)")
+	futuresCommand.Flags().StringVar(&futuresBaseURL, "base-url", "", "Override the exchange API base URL (e.g. a local mock server)")
+}
@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"btc-4h-prediction-model/internal/candlefile"
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/exchange"
+	"btc-4h-prediction-model/internal/store"
+)
+
+var importCommand = &cobra.Command{
+	Use:   "import",
+	Short: "Import candles from local files into the database",
+}
+
+var importArchiveDir string
+var importArchiveSymbol string
+var importArchiveTimeframe string
+var importArchiveAllowMissingChecksum bool
+
+var importArchiveCommand = &cobra.Command{
+	Use:   "archive",
+	Short: "Import data.binance.vision kline zip archives (verified against their CHECKSUM files)",
+	RunE: func(command *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if importArchiveDir == "" {
+			return fmt.Errorf("--dir is required")
+		}
+		if importArchiveTimeframe != "" {
+			timeframe, err := candles.ParseTimeframe(importArchiveTimeframe)
+			if err != nil {
+				return err
+			}
+			importArchiveTimeframe = timeframe.String()
+		}
+
+		database, err := store.OpenSQLite(databasePath)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		archivePaths, err := findArchives(importArchiveDir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("db:", databasePath)
+		fmt.Println("exchange:", exchangeName)
+		fmt.Println("dir:", importArchiveDir)
+
+		filesImported := 0
+		filesSkipped := 0
+		candlesUpserted := 0
+
+		for _, archivePath := range archivePaths {
+			symbol, timeframe, err := exchange.ParseBinanceArchiveName(archivePath)
+			if err != nil {
+				return err
+			}
+			if importArchiveSymbol != "" && symbol != importArchiveSymbol {
+				filesSkipped++
+				continue
+			}
+			parsedTimeframe, err := candles.ParseTimeframe(timeframe)
+			if err != nil {
+				return fmt.Errorf("%s: %w", filepath.Base(archivePath), err)
+			}
+			timeframe = parsedTimeframe.String()
+			if importArchiveTimeframe != "" && timeframe != importArchiveTimeframe {
+				filesSkipped++
+				continue
+			}
+
+			if err := exchange.VerifyBinanceArchiveChecksum(archivePath); err != nil {
+				if !(importArchiveAllowMissingChecksum && errors.Is(err, exchange.ErrBinanceArchiveChecksumMissing)) {
+					return err
+				}
+			}
+
+			archiveCandles, err := exchange.ReadBinanceArchive(archivePath, exchangeName, symbol, timeframe)
+			if err != nil {
+				return err
+			}
+			if err := store.UpsertCandles(ctx, database, archiveCandles); err != nil {
+				return fmt.Errorf("%s: %w", filepath.Base(archivePath), err)
+			}
+
+			fmt.Printf("imported: %s symbol=%s timeframe=%s candles=%d\n", filepath.Base(archivePath), symbol, timeframe, len(archiveCandles))
+			filesImported++
+			candlesUpserted += len(archiveCandles)
+		}
+
+		fmt.Println("files imported:", filesImported)
+		fmt.Println("files skipped:", filesSkipped)
+		fmt.Println("upserted:", candlesUpserted)
+
+		return nil
+	},
+}
+
+var importCandlesIn string
+var importCandlesFormat string
+var importCandlesColumns string
+var importCandlesTimestampUnit string
+var importCandlesSymbol string
+var importCandlesTimeframe string
+
+var importCandlesCommand = &cobra.Command{
+	Use:   "candles",
+	Short: "Import candles from a CSV or Parquet file (rows without an exchange column use --exchange)",
+	RunE: func(command *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if importCandlesIn == "" {
+			return fmt.Errorf("--in is required")
+		}
+		format, err := candlefile.ResolveFormat(importCandlesFormat, importCandlesIn)
+		if err != nil {
+			return err
+		}
+		options, err := candleFileOptions(importCandlesColumns, importCandlesTimestampUnit, importCandlesSymbol, importCandlesTimeframe)
+		if err != nil {
+			return err
+		}
+
+		candleSeries, err := candlefile.ReadFile(importCandlesIn, format, options)
+		if err != nil {
+			return fmt.Errorf("%s: %w", importCandlesIn, err)
+		}
+
+		database, err := store.OpenSQLite(databasePath)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		if err := store.UpsertCandles(ctx, database, candleSeries); err != nil {
+			return err
+		}
+
+		fmt.Println("db:", databasePath)
+		fmt.Println("in:", importCandlesIn)
+		fmt.Println("format:", format)
+		fmt.Println("upserted:", len(candleSeries))
+		return nil
+	},
+}
+
+func init() {
+	importArchiveCommand.Flags().StringVar(&importArchiveDir, "dir", "", "Directory containing *.zip kline archives (searched recursively)")
+	importArchiveCommand.Flags().StringVar(&importArchiveSymbol, "symbol", "", "Only import archives for this symbol (default: all)")
+	importArchiveCommand.Flags().StringVar(&importArchiveTimeframe, "timeframe", "", "Only import archives for this timeframe (default: all)")
+	importArchiveCommand.Flags().BoolVar(&importArchiveAllowMissingChecksum, "allow-missing-checksum", false, "Import archives that have no .CHECKSUM file (mismatches still fail)")
+
+	importCommand.AddCommand(importArchiveCommand)
+
+	importCandlesCommand.Flags().StringVar(&importCandlesIn, "in", "", "Input file path")
+	importCandlesCommand.Flags().StringVar(&importCandlesFormat, "format", "", "csv or parquet (default: from --in extension)")
+	importCandlesCommand.Flags().StringVar(&importCandlesColumns, "columns", "", "Column names as field=column pairs (e.g. timestamp=open_time,volume=vol)")
+	importCandlesCommand.Flags().StringVar(&importCandlesTimestampUnit, "timestamp-unit", "ms", "Unit of the timestamp/close_time columns: s, ms, us or ns")
+	importCandlesCommand.Flags().StringVar(&importCandlesSymbol, "symbol", "BTCUSDT", "Symbol for rows without a symbol column")
+	importCandlesCommand.Flags().StringVar(&importCandlesTimeframe, "timeframe", "4h", "Timeframe for rows without a timeframe column")
+
+	importCommand.AddCommand(importCandlesCommand)
+}
+
+// findArchives returns the zip files under dir in name order, so imports are deterministic.
+func findArchives(dir string) ([]string, error) {
+	var archivePaths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(strings.ToLower(entry.Name()), ".zip") {
+			archivePaths = append(archivePaths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(archivePaths)
+	return archivePaths, nil
+}
@@ -2,23 +2,35 @@ package cli
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"net/http"
+	"io"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"btc-4h-prediction-model/internal/candles"
 	"btc-4h-prediction-model/internal/exchange"
+	"btc-4h-prediction-model/internal/ingest"
 	"btc-4h-prediction-model/internal/store"
 )
 
 var ingestSymbol string
 var ingestTimeframe string
 var ingestDays int
+var ingestIncremental bool
+var ingestBackfillGaps bool
+var ingestBackfillOrderFlow bool
+var ingestWeightBudget int
+var ingestBaseURL string
+var ingestParallel bool
+var ingestWorkers int
+var ingestChunkDays int
 
 var ingestCommand = &cobra.Command{
-	Use:   "ingest",
-	Short: "Fetch Binance candles and upsert into the database",
+	Use:         "ingest",
+	Short:       "Fetch exchange candles and upsert into the database",
+	Annotations: reportOutput,
 	RunE: func(command *cobra.Command, args []string) error {
 		ctx := context.Background()
 
@@ -28,45 +40,295 @@ var ingestCommand = &cobra.Command{
 		}
 		defer database.Close()
 
-		if ingestDays <= 0 {
-			return fmt.Errorf("--days must be > 0")
+		report, err := runIngest(ctx, database, ingestOptions{
+			DB:                databasePath,
+			Exchange:          exchangeName,
+			Symbol:            ingestSymbol,
+			Timeframe:         ingestTimeframe,
+			Days:              ingestDays,
+			Incremental:       ingestIncremental,
+			BackfillGaps:      ingestBackfillGaps,
+			BackfillOrderFlow: ingestBackfillOrderFlow,
+			WeightBudget:      ingestWeightBudget,
+			BaseURL:           ingestBaseURL,
+			Parallel:          ingestParallel,
+			Workers:           ingestWorkers,
+			ChunkDays:         ingestChunkDays,
+		})
+		if err != nil {
+			return err
 		}
+		return writeReport(report)
+	},
+}
+
+// ingestOptions are the ingest flags; quant run fills them from the pipeline config.
+type ingestOptions struct {
+	DB                string // database path, shown in the report
+	Exchange          string
+	Symbol            string
+	Timeframe         string
+	Days              int
+	Incremental       bool
+	BackfillGaps      bool
+	BackfillOrderFlow bool
+	WeightBudget      int
+	BaseURL           string
+	Parallel          bool
+	Workers           int
+	ChunkDays         int
+}
+
+func runIngest(ctx context.Context, database *sql.DB, options ingestOptions) (ingestReport, error) {
+	if options.Days <= 0 {
+		return ingestReport{}, fmt.Errorf("--days must be > 0")
+	}
 
-		// v0.1: look back N days from now
-		now := time.Now().UTC()
-		startTimeMillis := now.Add(time.Duration(-ingestDays) * 24 * time.Hour).UnixMilli()
+	source, err := exchange.NewCandleSource(options.Exchange, exchange.SourceOptions{
+		WeightBudget: options.WeightBudget,
+		BaseURL:      options.BaseURL,
+	})
+	if err != nil {
+		return ingestReport{}, err
+	}
+	timeframe, err := candles.ParseTimeframe(options.Timeframe)
+	if err != nil {
+		return ingestReport{}, err
+	}
+	options.Timeframe = timeframe.String()
+	if !exchange.SupportsTimeframe(source, options.Timeframe) {
+		return ingestReport{}, fmt.Errorf("%s does not support timeframe %q", source.Name(), options.Timeframe)
+	}
 
-		binanceClient := exchange.BinanceClient{HTTPClient: http.DefaultClient}
+	// v0.1: look back N days from now
+	now := time.Now().UTC()
+	startTimeMillis := now.Add(time.Duration(-options.Days) * 24 * time.Hour).UnixMilli()
 
-		candlesFetched, err := binanceClient.FetchKlinesPaginated(
-			ctx,
-			ingestSymbol,
-			ingestTimeframe,
-			startTimeMillis,
-			0, // no endTime
-		)
+	mode := "lookback"
+	if options.Incremental {
+		latestTimestamp, found, err := store.LatestFinalCandleTimestamp(ctx, database, source.Name(), options.Symbol, options.Timeframe)
 		if err != nil {
-			return err
+			return ingestReport{}, err
 		}
+		// Empty series: fall back to the --days lookback for the first run
+		if found {
+			startTimeMillis = timeframe.Next(latestTimestamp)
+			mode = "incremental"
+		}
+	}
 
-		if err := store.UpsertCandles(ctx, database, candlesFetched); err != nil {
-			return err
+	candlesFetched := 0
+	openCandles := 0
+	if startTimeMillis <= now.UnixMilli() {
+		if options.Parallel {
+			if options.Workers <= 0 || options.ChunkDays <= 0 {
+				return ingestReport{}, fmt.Errorf("--workers and --chunk-days must be > 0")
+			}
+			candlesFetched, err = exchange.BackfillCandles(
+				ctx,
+				source,
+				options.Symbol,
+				options.Timeframe,
+				startTimeMillis,
+				now.UnixMilli(),
+				exchange.BackfillConfig{
+					ChunkMillis: int64(options.ChunkDays) * 24 * 60 * 60 * 1000,
+					Workers:     options.Workers,
+				},
+				func(chunkCandles []candles.Candle) error {
+					openCandles += countOpenCandles(chunkCandles)
+					return store.UpsertCandles(ctx, database, chunkCandles)
+				},
+			)
+			if err != nil {
+				return ingestReport{}, err
+			}
+		} else {
+			fetched, err := source.FetchCandles(
+				ctx,
+				options.Symbol,
+				options.Timeframe,
+				startTimeMillis,
+				0, // no endTime
+			)
+			if err != nil {
+				return ingestReport{}, err
+			}
+			if err := store.UpsertCandles(ctx, database, fetched); err != nil {
+				return ingestReport{}, err
+			}
+			candlesFetched = len(fetched)
+			openCandles = countOpenCandles(fetched)
 		}
+	}
 
-		fmt.Println("db:", databasePath)
-		fmt.Println("exchange: binance")
-		fmt.Println("symbol:", ingestSymbol)
-		fmt.Println("timeframe:", ingestTimeframe)
-		fmt.Println("days:", ingestDays)
-		fmt.Println("fetched:", len(candlesFetched))
-		fmt.Println("upserted:", len(candlesFetched))
+	gapCandles := 0
+	if options.BackfillGaps {
+		gapCandles, err = backfillGaps(ctx, database, source, options.Symbol, timeframe)
+		if err != nil {
+			return ingestReport{}, err
+		}
+	}
 
-		return nil
-	},
+	orderFlowCandles := 0
+	if options.BackfillOrderFlow {
+		orderFlowCandles, err = backfillOrderFlow(ctx, database, source, options.Symbol, timeframe)
+		if err != nil {
+			return ingestReport{}, err
+		}
+	}
+
+	return ingestReport{
+		DB:                       options.DB,
+		Exchange:                 options.Exchange,
+		Symbol:                   options.Symbol,
+		Timeframe:                options.Timeframe,
+		Mode:                     mode,
+		Days:                     options.Days,
+		StartTS:                  startTimeMillis,
+		Parallel:                 options.Parallel,
+		Workers:                  options.Workers,
+		ChunkDays:                options.ChunkDays,
+		Fetched:                  candlesFetched,
+		Upserted:                 candlesFetched,
+		InProgress:               openCandles,
+		BackfillGaps:             options.BackfillGaps,
+		GapCandlesUpserted:       gapCandles,
+		BackfillOrderFlow:        options.BackfillOrderFlow,
+		OrderFlowCandlesUpserted: orderFlowCandles,
+	}, nil
+}
+
+type ingestReport struct {
+	DB        string `json:"db"`
+	Exchange  string `json:"exchange"`
+	Symbol    string `json:"symbol"`
+	Timeframe string `json:"timeframe"`
+
+	Mode      string `json:"mode"` // lookback or incremental
+	Days      int    `json:"days"`
+	StartTS   int64  `json:"start_ts"`
+	Parallel  bool   `json:"parallel"`
+	Workers   int    `json:"workers"`
+	ChunkDays int    `json:"chunk_days"`
+
+	Fetched    int `json:"fetched"`
+	Upserted   int `json:"upserted"`
+	InProgress int `json:"in_progress"`
+
+	BackfillGaps             bool `json:"backfill_gaps"`
+	GapCandlesUpserted       int  `json:"gap_candles_upserted"`
+	BackfillOrderFlow        bool `json:"backfill_order_flow"`
+	OrderFlowCandlesUpserted int  `json:"order_flow_candles_upserted"`
+}
+
+func (report ingestReport) writeText(writer io.Writer) {
+	fmt.Fprintln(writer, "db:", report.DB)
+	fmt.Fprintln(writer, "exchange:", report.Exchange)
+	fmt.Fprintln(writer, "symbol:", report.Symbol)
+	fmt.Fprintln(writer, "timeframe:", report.Timeframe)
+	fmt.Fprintln(writer, "mode:", report.Mode)
+	if report.Mode == "lookback" {
+		fmt.Fprintln(writer, "days:", report.Days)
+	}
+	fmt.Fprintln(writer, "start ts:", report.StartTS)
+	if report.Parallel {
+		fmt.Fprintln(writer, "workers:", report.Workers, "chunk days:", report.ChunkDays)
+	}
+	fmt.Fprintln(writer, "fetched:", report.Fetched)
+	fmt.Fprintln(writer, "upserted:", report.Upserted)
+	fmt.Fprintln(writer, "in-progress:", report.InProgress)
+	if report.BackfillGaps {
+		fmt.Fprintln(writer, "gap candles upserted:", report.GapCandlesUpserted)
+	}
+	if report.BackfillOrderFlow {
+		fmt.Fprintln(writer, "order-flow candles upserted:", report.OrderFlowCandlesUpserted)
+	}
+}
+
+func (report ingestReport) csvTable() ([]string, [][]string) {
+	return singleRowTable(
+		csvField{"exchange", report.Exchange},
+		csvField{"symbol", report.Symbol},
+		csvField{"timeframe", report.Timeframe},
+		csvField{"mode", report.Mode},
+		csvField{"start_ts", csvInt(report.StartTS)},
+		csvField{"fetched", csvInt(report.Fetched)},
+		csvField{"upserted", csvInt(report.Upserted)},
+		csvField{"in_progress", csvInt(report.InProgress)},
+		csvField{"gap_candles_upserted", csvInt(report.GapCandlesUpserted)},
+		csvField{"order_flow_candles_upserted", csvInt(report.OrderFlowCandlesUpserted)},
+	)
 }
 
 func init() {
 	ingestCommand.Flags().StringVar(&ingestSymbol, "symbol", "BTCUSDT", "Symbol (e.g. BTCUSDT)")
 	ingestCommand.Flags().StringVar(&ingestTimeframe, "timeframe", "4h", "Timeframe (e.g. 4h)")
 	ingestCommand.Flags().IntVar(&ingestDays, "days", 30, "Lookback window in days")
+	ingestCommand.Flags().BoolVar(&ingestIncremental, "incremental", false, "Resume from the last stored candle (uses --days only when the series is empty)")
+	ingestCommand.Flags().IntVar(&ingestWeightBudget, "weight-budget", 0, "Request weight to spend per minute on weight-metered exchanges like Binance (0 = exchange default, -1 disables client-side limiting)")
+	ingestCommand.Flags().StringVar(&ingestBaseURL, "base-url", "", "Override the exchange API base URL (e.g. a local mock server)")
+	ingestCommand.Flags().BoolVar(&ingestParallel, "parallel", false, "Fetch the range in time chunks with a worker pool, upserting each chunk as it arrives")
+	ingestCommand.Flags().IntVar(&ingestWorkers, "workers", 4, "Concurrent chunk fetches for --parallel (they share the exchange rate limit)")
+	ingestCommand.Flags().IntVar(&ingestChunkDays, "chunk-days", 30, "Chunk width in days for --parallel")
+	ingestCommand.Flags().BoolVar(&ingestBackfillGaps, "backfill-gaps", false, "Refetch ranges reported as gaps by continuity validation")
+	ingestCommand.Flags().BoolVar(&ingestBackfillOrderFlow, "backfill-order-flow", false, "Refetch stored candles that have no quote volume/trades/taker volume yet (skipped for exchanges that do not report them)")
+}
+
+// backfillGaps refetches the missing range of every aligned gap in the stored series.
+func backfillGaps(ctx context.Context, database *sql.DB, source exchange.CandleSource, symbol string, timeframe candles.Timeframe) (int, error) {
+	validationResult, err := ingest.ValidateCandleContinuity(
+		ctx,
+		database,
+		source.Name(),
+		symbol,
+		timeframe,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return ingest.RefetchGaps(ctx, database, source, symbol, timeframe, validationResult.Gaps)
+}
+
+func countOpenCandles(candleSeries []candles.Candle) int {
+	open := 0
+	for _, candle := range candleSeries {
+		if !candle.IsFinal {
+			open++
+		}
+	}
+	return open
+}
+
+// backfillOrderFlow refetches runs of stored candles that predate the order-flow columns so the
+// upsert fills them in. Sources that do not report these fields are skipped: a refetch would
+// leave the rows NULL and every later run would fetch the whole history again.
+func backfillOrderFlow(ctx context.Context, database *sql.DB, source exchange.CandleSource, symbol string, timeframe candles.Timeframe) (int, error) {
+	if !source.ReportsOrderFlow() {
+		return 0, nil
+	}
+	missing, err := store.MissingOrderFlowTimestamps(ctx, database, source.Name(), symbol, timeframe.String())
+	if err != nil {
+		return 0, err
+	}
+
+	upserted := 0
+	for runStart := 0; runStart < len(missing); {
+		runEnd := runStart
+		for runEnd+1 < len(missing) && missing[runEnd+1] == timeframe.Next(missing[runEnd]) {
+			runEnd++
+		}
+
+		runCandles, err := source.FetchCandles(ctx, symbol, timeframe.String(), missing[runStart], missing[runEnd])
+		if err != nil {
+			return upserted, err
+		}
+		if err := store.UpsertCandles(ctx, database, runCandles); err != nil {
+			return upserted, err
+		}
+		upserted += len(runCandles)
+		runStart = runEnd + 1
+	}
+	return upserted, nil
 }
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/store"
+)
+
+const fourHourMillis = 4 * 60 * 60 * 1000
+
+// orderFlowSource serves 4h candles with or without order flow and records every fetch.
+type orderFlowSource struct {
+	orderFlow bool
+	fetches   *[][2]int64
+}
+
+func (source orderFlowSource) Name() string           { return "test" }
+func (source orderFlowSource) Timeframes() []string   { return []string{"4h"} }
+func (source orderFlowSource) ReportsOrderFlow() bool { return source.orderFlow }
+
+func (source orderFlowSource) FetchCandles(ctx context.Context, symbol string, timeframe string, startTimeMillis int64, endTimeMillis int64) ([]candles.Candle, error) {
+	*source.fetches = append(*source.fetches, [2]int64{startTimeMillis, endTimeMillis})
+	var result []candles.Candle
+	for open := startTimeMillis; open <= endTimeMillis; open += fourHourMillis {
+		candle := testCandle(open)
+		if source.orderFlow {
+			quoteVolume, trades, takerBase, takerQuote := 100.5, int64(7), 0.4, 40.2
+			candle.QuoteVolume, candle.Trades, candle.TakerBuyBaseVolume, candle.TakerBuyQuoteVolume = &quoteVolume, &trades, &takerBase, &takerQuote
+		}
+		result = append(result, candle)
+	}
+	return result, nil
+}
+
+func testCandle(open int64) candles.Candle {
+	return candles.Candle{
+		Exchange: "test", Symbol: "BTCUSDT", Timeframe: "4h", Timestamp: open,
+		Open: 100, High: 101, Low: 99, Close: 100.5, Volume: 1, CloseTime: open + fourHourMillis - 1, IsFinal: true,
+	}
+}
+
+func openTestDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := store.OpenSQLite(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestBackfillOrderFlow(t *testing.T) {
+	const start = 1709251200000
+	timeframe, err := candles.ParseTimeframe("4h")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		orderFlow   bool
+		wantFetches [][2]int64
+		wantMissing int
+	}{
+		// One run per stretch of stored bars, with the gap at bar 3 splitting them
+		{"source with order flow", true, [][2]int64{{start, start + 2*fourHourMillis}, {start + 4*fourHourMillis, start + 5*fourHourMillis}}, 0},
+		// Refetching could never fill the columns, so nothing is fetched (now or on any later run)
+		{"source without order flow", false, nil, 5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := openTestDatabase(t)
+			var stored []candles.Candle
+			for _, bar := range []int64{0, 1, 2, 4, 5} {
+				stored = append(stored, testCandle(start+bar*fourHourMillis))
+			}
+			if err := store.UpsertCandles(ctx, db, stored); err != nil {
+				t.Fatal(err)
+			}
+
+			var fetches [][2]int64
+			source := orderFlowSource{orderFlow: test.orderFlow, fetches: &fetches}
+			for run := 0; run < 2; run++ {
+				if _, err := backfillOrderFlow(ctx, db, source, "BTCUSDT", timeframe); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The second run finds nothing left to refetch either way
+			if len(fetches) != len(test.wantFetches) {
+				t.Fatalf("fetched %v, want %v", fetches, test.wantFetches)
+			}
+			for i := range fetches {
+				if fetches[i] != test.wantFetches[i] {
+					t.Fatalf("fetched %v, want %v", fetches, test.wantFetches)
+				}
+			}
+			missing, err := store.MissingOrderFlowTimestamps(ctx, db, "test", "BTCUSDT", "4h")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(missing) != test.wantMissing {
+				t.Fatalf("%d candles still lack order flow, want %d", len(missing), test.wantMissing)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 
@@ -15,8 +17,9 @@ var labelsTimeframe string
 var labelsThresholdB float64
 
 var labelsCommand = &cobra.Command{
-	Use:   "labels",
-	Short: "Compute forward-return labels (UP/DOWN/NO_TRADE) and store them in the database",
+	Use:         "labels",
+	Short:       "Compute forward-return labels (UP/DOWN/NO_TRADE) and store them in the database",
+	Annotations: reportOutput,
 	RunE: func(command *cobra.Command, args []string) error {
 		ctx := context.Background()
 
@@ -26,33 +29,85 @@ var labelsCommand = &cobra.Command{
 		}
 		defer db.Close()
 
-		candleSeries, err := store.LoadCandlesOrdered(ctx, db, "binance", labelsSymbol, labelsTimeframe)
+		report, err := runLabels(ctx, db, labelsOptions{
+			DB:         databasePath,
+			Exchange:   exchangeName,
+			Symbol:     labelsSymbol,
+			Timeframe:  labelsTimeframe,
+			ThresholdB: labelsThresholdB,
+		})
 		if err != nil {
 			return err
 		}
-		if len(candleSeries) < 2 {
-			return fmt.Errorf("not enough candles to label (%d)", len(candleSeries))
-		}
+		return writeReport(report)
+	},
+}
 
-		labelRows, err := labels.BuildForwardReturnLabels(candleSeries, labelsThresholdB)
-		if err != nil {
-			return err
-		}
+// labelsOptions are the labels flags; quant run fills them from the pipeline config.
+type labelsOptions struct {
+	DB         string // database path, shown in the report
+	Exchange   string
+	Symbol     string
+	Timeframe  string
+	ThresholdB float64
+}
 
-		if err := store.UpsertLabels(ctx, db, labelRows); err != nil {
-			return err
-		}
+func runLabels(ctx context.Context, db *sql.DB, options labelsOptions) (labelsReport, error) {
+	candleSeries, err := store.LoadCandlesOrdered(ctx, db, options.Exchange, options.Symbol, options.Timeframe)
+	if err != nil {
+		return labelsReport{}, err
+	}
+	if len(candleSeries) < 2 {
+		return labelsReport{}, fmt.Errorf("not enough candles to label (%d)", len(candleSeries))
+	}
 
-		fmt.Println("db:", databasePath)
-		fmt.Println("exchange: binance")
-		fmt.Println("symbol:", labelsSymbol)
-		fmt.Println("timeframe:", labelsTimeframe)
-		fmt.Println("b:", labelsThresholdB)
-		fmt.Println("labels upserted:", len(labelRows))
-		fmt.Println("note: last candle has no label (no future candle)")
+	labelRows, err := labels.BuildForwardReturnLabels(candleSeries, options.ThresholdB)
+	if err != nil {
+		return labelsReport{}, err
+	}
 
-		return nil
-	},
+	if err := store.UpsertLabels(ctx, db, labelRows); err != nil {
+		return labelsReport{}, err
+	}
+
+	return labelsReport{
+		DB:           options.DB,
+		Exchange:     options.Exchange,
+		Symbol:       options.Symbol,
+		Timeframe:    options.Timeframe,
+		ThresholdB:   options.ThresholdB,
+		RowsUpserted: len(labelRows),
+	}, nil
+}
+
+type labelsReport struct {
+	DB        string `json:"db"`
+	Exchange  string `json:"exchange"`
+	Symbol    string `json:"symbol"`
+	Timeframe string `json:"timeframe"`
+
+	ThresholdB   float64 `json:"threshold_b"`
+	RowsUpserted int     `json:"rows_upserted"`
+}
+
+func (report labelsReport) writeText(writer io.Writer) {
+	fmt.Fprintln(writer, "db:", report.DB)
+	fmt.Fprintln(writer, "exchange:", report.Exchange)
+	fmt.Fprintln(writer, "symbol:", report.Symbol)
+	fmt.Fprintln(writer, "timeframe:", report.Timeframe)
+	fmt.Fprintln(writer, "b:", report.ThresholdB)
+	fmt.Fprintln(writer, "labels upserted:", report.RowsUpserted)
+	fmt.Fprintln(writer, "note: last candle has no label (no future candle)")
+}
+
+func (report labelsReport) csvTable() ([]string, [][]string) {
+	return singleRowTable(
+		csvField{"exchange", report.Exchange},
+		csvField{"symbol", report.Symbol},
+		csvField{"timeframe", report.Timeframe},
+		csvField{"threshold_b", csvFloat(report.ThresholdB)},
+		csvField{"rows_upserted", csvInt(report.RowsUpserted)},
+	)
 }
 
 func init() {
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"btc-4h-prediction-model/internal/store"
+)
+
+var migrateCommand = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations to the database",
+	RunE: func(command *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		database, err := store.OpenSQLiteWithoutMigrations(databasePath)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		status, err := store.Migrate(ctx, database)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("db:", databasePath)
+		for _, migration := range status.Baselined {
+			fmt.Printf("baselined: %05d_%s (already in place)\n", migration.Version, migration.Name)
+		}
+		for _, migration := range status.Applied {
+			fmt.Printf("applied: %05d_%s\n", migration.Version, migration.Name)
+		}
+		fmt.Println("applied count:", len(status.Applied))
+		fmt.Println("schema version:", status.CurrentVersion)
+		fmt.Println("latest version:", status.LatestVersion)
+
+		return nil
+	},
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputCSV  = "csv"
+)
+
+var outputFormat string
+
+// reportWriter receives every report; errors never go here.
+var reportWriter io.Writer = os.Stdout
+
+// commandReport is everything a command prints. JSON encodes the report value itself, so its
+// json tags are the stable schema; CSV writes the command's main table; text is the
+// human-readable layout.
+type commandReport interface {
+	writeText(writer io.Writer)
+	csvTable() (header []string, records [][]string)
+}
+
+// outputAnnotation marks the commands that print through writeReport; the others only print
+// text and reject --output json/csv rather than silently ignoring it.
+const outputAnnotation = "output"
+
+var reportOutput = map[string]string{outputAnnotation: "text,json,csv"}
+
+func validateOutputFormat(command *cobra.Command) error {
+	switch outputFormat {
+	case outputText:
+		return nil
+	case outputJSON, outputCSV:
+		if command.Annotations[outputAnnotation] == "" {
+			return fmt.Errorf("%s does not support --output %s (text only)", command.CommandPath(), outputFormat)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported --output %q (text, json, csv)", outputFormat)
+	}
+}
+
+func writeReport(report commandReport) error {
+	switch outputFormat {
+	case outputJSON:
+		encoder := json.NewEncoder(reportWriter)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case outputCSV:
+		header, records := report.csvTable()
+		csvWriter := csv.NewWriter(reportWriter)
+		if err := csvWriter.Write(header); err != nil {
+			return err
+		}
+		return csvWriter.WriteAll(records)
+	default:
+		report.writeText(reportWriter)
+		return nil
+	}
+}
+
+// csvField is one column of a single-row CSV summary.
+type csvField struct {
+	name  string
+	value string
+}
+
+func singleRowTable(fields ...csvField) ([]string, [][]string) {
+	header := make([]string, len(fields))
+	record := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = field.name
+		record[i] = field.value
+	}
+	return header, [][]string{record}
+}
+
+func csvInt[T int | int64](value T) string {
+	return strconv.FormatInt(int64(value), 10)
+}
+
+func csvFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
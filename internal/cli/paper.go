@@ -2,7 +2,9 @@ package cli
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
 
@@ -21,8 +23,9 @@ var paperSlippage float64
 var paperOutDir string
 
 var paperCommand = &cobra.Command{
-	Use:   "paper",
-	Short: "Paper trading simulator (4H horizon) using stored out-of-sample predictions",
+	Use:         "paper",
+	Short:       "Paper trading simulator (4H horizon) using stored out-of-sample predictions",
+	Annotations: reportOutput,
 	RunE: func(command *cobra.Command, args []string) error {
 		ctx := context.Background()
 
@@ -37,70 +40,155 @@ var paperCommand = &cobra.Command{
 			return err
 		}
 
-		rows, err := backtest.LoadPredictionsWithForwardReturn(ctx, db, "binance", paperSymbol, paperTimeframe, paperModelName)
+		report, err := runPaper(ctx, db, paperOptions{
+			DB:         databasePath,
+			Exchange:   exchangeName,
+			Symbol:     paperSymbol,
+			Timeframe:  paperTimeframe,
+			ModelName:  paperModelName,
+			Thresholds: thresholdList,
+			Fee:        paperFee,
+			Slippage:   paperSlippage,
+			OutDir:     paperOutDir,
+		})
 		if err != nil {
 			return err
 		}
-		if len(rows) == 0 {
-			return fmt.Errorf("no predictions found for %s %s model=%s", paperSymbol, paperTimeframe, paperModelName)
-		}
+		return writeReport(report)
+	},
+}
 
-		fmt.Println("db:", databasePath)
-		fmt.Println("exchange: binance")
-		fmt.Println("symbol:", paperSymbol)
-		fmt.Println("timeframe:", paperTimeframe)
-		fmt.Println("model:", paperModelName)
-		fmt.Println("predictions:", len(rows))
-		fmt.Println("feePerSide:", paperFee, "slippage:", paperSlippage)
-		fmt.Println()
-
-		fmt.Printf("%-8s %-10s %-10s %-12s %-12s %-12s %-12s\n",
-			"thr", "trades", "coverage", "total_ret", "sharpe", "max_dd", "csv",
-		)
+// paperOptions are the paper flags; quant run fills them from the pipeline config.
+type paperOptions struct {
+	DB         string // database path, shown in the report
+	Exchange   string
+	Symbol     string
+	Timeframe  string
+	ModelName  string
+	Thresholds []float64
+	Fee        float64
+	Slippage   float64
+	OutDir     string // empty disables equity CSVs
+}
+
+func runPaper(ctx context.Context, db *sql.DB, options paperOptions) (paperReport, error) {
+	rows, err := backtest.LoadPredictionsWithForwardReturn(ctx, db, options.Exchange, options.Symbol, options.Timeframe, options.ModelName)
+	if err != nil {
+		return paperReport{}, err
+	}
+	if len(rows) == 0 {
+		return paperReport{}, fmt.Errorf("no predictions found for %s %s model=%s", options.Symbol, options.Timeframe, options.ModelName)
+	}
 
-		for _, thr := range thresholdList {
-			csvPath := ""
-			if paperOutDir != "" {
-				csvPath = filepath.Join(
-					paperOutDir,
-					fmt.Sprintf("equity_%s_%s_thr%.2f.csv", strings.ToLower(paperSymbol), strings.ToLower(paperTimeframe), thr),
-				)
-			}
-
-			result, err := backtest.RunPaperBacktest(rows, backtest.PaperConfig{
-				Exchange:  "binance",
-				Symbol:    paperSymbol,
-				Timeframe: paperTimeframe,
-				ModelName: paperModelName,
-
-				Threshold:  thr,
-				FeePerSide: paperFee,
-				Slippage:   paperSlippage,
-
-				EquityCSVPath: csvPath,
-			})
-			if err != nil {
-				return err
-			}
-
-			fmt.Printf("%-8.2f %-10d %-10.3f %-12.4f %-12.4f %-12.4f %-12s\n",
-				result.Threshold,
-				result.Trades,
-				result.Coverage,
-				result.TotalReturn,
-				result.Sharpe,
-				result.MaxDrawdown,
-				shortPath(csvPath),
+	report := paperReport{
+		DB:          options.DB,
+		Exchange:    options.Exchange,
+		Symbol:      options.Symbol,
+		Timeframe:   options.Timeframe,
+		Model:       options.ModelName,
+		Predictions: len(rows),
+		FeePerSide:  options.Fee,
+		Slippage:    options.Slippage,
+	}
+
+	for _, thr := range options.Thresholds {
+		csvPath := ""
+		if options.OutDir != "" {
+			csvPath = filepath.Join(
+				options.OutDir,
+				fmt.Sprintf("equity_%s_%s_thr%.2f.csv", strings.ToLower(options.Symbol), strings.ToLower(options.Timeframe), thr),
 			)
 		}
 
-		fmt.Println()
-		fmt.Println("Notes:")
-		fmt.Println("- This baseline exits at next candle close (matches label horizon).")
-		fmt.Println("- CSV equity curves are written if --out is set (plotting comes next).")
+		result, err := backtest.RunPaperBacktest(rows, backtest.PaperConfig{
+			Exchange:  options.Exchange,
+			Symbol:    options.Symbol,
+			Timeframe: options.Timeframe,
+			ModelName: options.ModelName,
 
-		return nil
-	},
+			Threshold:  thr,
+			FeePerSide: options.Fee,
+			Slippage:   options.Slippage,
+
+			EquityCSVPath: csvPath,
+		})
+		if err != nil {
+			return paperReport{}, err
+		}
+
+		report.Results = append(report.Results, paperThresholdResult{PaperResult: result, EquityCSV: csvPath})
+	}
+	return report, nil
+}
+
+type paperReport struct {
+	DB        string `json:"db"`
+	Exchange  string `json:"exchange"`
+	Symbol    string `json:"symbol"`
+	Timeframe string `json:"timeframe"`
+	Model     string `json:"model"`
+
+	Predictions int     `json:"predictions"`
+	FeePerSide  float64 `json:"fee_per_side"`
+	Slippage    float64 `json:"slippage"`
+
+	Results []paperThresholdResult `json:"results"`
+}
+
+type paperThresholdResult struct {
+	backtest.PaperResult
+	EquityCSV string `json:"equity_csv"` // empty when --out is disabled
+}
+
+func (report paperReport) writeText(writer io.Writer) {
+	fmt.Fprintln(writer, "db:", report.DB)
+	fmt.Fprintln(writer, "exchange:", report.Exchange)
+	fmt.Fprintln(writer, "symbol:", report.Symbol)
+	fmt.Fprintln(writer, "timeframe:", report.Timeframe)
+	fmt.Fprintln(writer, "model:", report.Model)
+	fmt.Fprintln(writer, "predictions:", report.Predictions)
+	fmt.Fprintln(writer, "feePerSide:", report.FeePerSide, "slippage:", report.Slippage)
+	fmt.Fprintln(writer)
+
+	fmt.Fprintf(writer, "%-8s %-10s %-10s %-12s %-12s %-12s %-12s\n",
+		"thr", "trades", "coverage", "total_ret", "sharpe", "max_dd", "csv",
+	)
+
+	for _, result := range report.Results {
+		fmt.Fprintf(writer, "%-8.2f %-10d %-10.3f %-12.4f %-12.4f %-12.4f %-12s\n",
+			result.Threshold,
+			result.Trades,
+			result.Coverage,
+			result.TotalReturn,
+			result.Sharpe,
+			result.MaxDrawdown,
+			shortPath(result.EquityCSV),
+		)
+	}
+
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "Notes:")
+	fmt.Fprintln(writer, "- This baseline exits at next candle close (matches label horizon).")
+	fmt.Fprintln(writer, "- CSV equity curves are written if --out is set (plotting comes next).")
+}
+
+func (report paperReport) csvTable() ([]string, [][]string) {
+	header := []string{"threshold", "predictions", "trades", "coverage", "total_return", "sharpe", "max_drawdown", "end_equity", "equity_csv"}
+	records := make([][]string, 0, len(report.Results))
+	for _, result := range report.Results {
+		records = append(records, []string{
+			csvFloat(result.Threshold),
+			csvInt(result.Predictions),
+			csvInt(result.Trades),
+			csvFloat(result.Coverage),
+			csvFloat(result.TotalReturn),
+			csvFloat(result.Sharpe),
+			csvFloat(result.MaxDrawdown),
+			csvFloat(result.EndEquity),
+			result.EquityCSV,
+		})
+	}
+	return header, records
 }
 
 func init() {
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"btc-4h-prediction-model/internal/ingest"
+	"btc-4h-prediction-model/internal/store"
+)
+
+var reconcileLeft string
+var reconcileRight string
+var reconcileTimeframe string
+var reconcileThreshold float64
+var reconcileMaxDivergences int
+var reconcileFailOnDivergence bool
+
+var reconcileCommand = &cobra.Command{
+	Use:         "reconcile",
+	Short:       "Compare two stored candle series (e.g. the same pair on two exchanges) and flag diverging closes",
+	Annotations: reportOutput,
+	RunE: func(command *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		leftExchange, leftSymbol, err := parseSeriesKey(reconcileLeft)
+		if err != nil {
+			return fmt.Errorf("--left: %w", err)
+		}
+		rightExchange, rightSymbol, err := parseSeriesKey(reconcileRight)
+		if err != nil {
+			return fmt.Errorf("--right: %w", err)
+		}
+		if reconcileThreshold <= 0 {
+			return fmt.Errorf("--threshold must be > 0")
+		}
+
+		db, err := store.OpenSQLite(databasePath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		left, err := store.LoadCandlesOrdered(ctx, db, leftExchange, leftSymbol, reconcileTimeframe)
+		if err != nil {
+			return err
+		}
+		right, err := store.LoadCandlesOrdered(ctx, db, rightExchange, rightSymbol, reconcileTimeframe)
+		if err != nil {
+			return err
+		}
+		if len(left) == 0 || len(right) == 0 {
+			return fmt.Errorf("no candles for %s (%d) or %s (%d) at %s", reconcileLeft, len(left), reconcileRight, len(right), reconcileTimeframe)
+		}
+
+		result := ingest.ReconcileCandles(left, right, reconcileThreshold)
+		report := reconcileReport{
+			DB:                 databasePath,
+			Left:               reconcileLeft,
+			LeftCandles:        len(left),
+			Right:              reconcileRight,
+			RightCandles:       len(right),
+			Timeframe:          reconcileTimeframe,
+			Threshold:          reconcileThreshold,
+			Matched:            result.Matched,
+			LeftOnly:           result.LeftOnly,
+			RightOnly:          result.RightOnly,
+			MeanCloseRelDiff:   finiteOrNil(result.MeanCloseRelDiff),
+			MedianCloseRelDiff: finiteOrNil(result.MedianCloseRelDiff),
+			MedianVolumeRatio:  finiteOrNil(result.MedianVolumeRatio),
+			VolumeCorrelation:  finiteOrNil(result.VolumeCorrelation),
+			Divergences:        make([]reconcileDivergence, len(result.Divergences)),
+			MaxDivergences:     reconcileMaxDivergences,
+		}
+		if report.MeanCloseRelDiff != nil {
+			report.MaxCloseRelDiff = &result.MaxCloseRelDiff
+			report.MaxCloseRelDiffTS = &result.MaxCloseRelDiffTS
+		}
+		for i, divergence := range result.Divergences {
+			report.Divergences[i] = reconcileDivergence(divergence)
+		}
+		if err := writeReport(report); err != nil {
+			return err
+		}
+
+		if reconcileFailOnDivergence && len(result.Divergences) > 0 {
+			command.SilenceUsage = true
+			return fmt.Errorf("reconcile failed: %d diverging bar(s)", len(result.Divergences))
+		}
+		return nil
+	},
+}
+
+type reconcileReport struct {
+	DB           string  `json:"db"`
+	Left         string  `json:"left"`
+	LeftCandles  int     `json:"left_candles"`
+	Right        string  `json:"right"`
+	RightCandles int     `json:"right_candles"`
+	Timeframe    string  `json:"timeframe"`
+	Threshold    float64 `json:"threshold"`
+
+	Matched   int `json:"matched"`
+	LeftOnly  int `json:"left_only"`
+	RightOnly int `json:"right_only"`
+
+	// Statistics over matched bars; null when the series share no timestamps (the volume
+	// statistics also when too few bars traded on both)
+	MeanCloseRelDiff   *float64 `json:"mean_close_rel_diff"`
+	MedianCloseRelDiff *float64 `json:"median_close_rel_diff"`
+	MaxCloseRelDiff    *float64 `json:"max_close_rel_diff"`
+	MaxCloseRelDiffTS  *int64   `json:"max_close_rel_diff_ts"`
+	MedianVolumeRatio  *float64 `json:"median_volume_ratio"`
+	VolumeCorrelation  *float64 `json:"volume_correlation"`
+
+	// Every divergence, regardless of --max-divergences
+	Divergences []reconcileDivergence `json:"divergences"`
+
+	MaxDivergences int `json:"-"`
+}
+
+type reconcileDivergence struct {
+	Timestamp  int64   `json:"timestamp"`
+	LeftClose  float64 `json:"left_close"`
+	RightClose float64 `json:"right_close"`
+	RelDiff    float64 `json:"rel_diff"`
+}
+
+func (report reconcileReport) writeText(writer io.Writer) {
+	fmt.Fprintln(writer, "db:", report.DB)
+	fmt.Fprintln(writer, "left:", report.Left, "candles:", report.LeftCandles)
+	fmt.Fprintln(writer, "right:", report.Right, "candles:", report.RightCandles)
+	fmt.Fprintln(writer, "timeframe:", report.Timeframe)
+	fmt.Fprintln(writer, "matched:", report.Matched)
+	fmt.Fprintln(writer, "left only:", report.LeftOnly)
+	fmt.Fprintln(writer, "right only:", report.RightOnly)
+	if report.MeanCloseRelDiff == nil {
+		fmt.Fprintln(writer, "no overlapping candles")
+		return
+	}
+
+	fmt.Fprintf(writer, "close rel diff: mean=%.6f median=%.6f max=%.6f (ts=%d)\n",
+		*report.MeanCloseRelDiff, *report.MedianCloseRelDiff, *report.MaxCloseRelDiff, *report.MaxCloseRelDiffTS)
+	fmt.Fprintf(writer, "volume: median ratio (left/right)=%s log-volume correlation=%s\n",
+		formatOptional(report.MedianVolumeRatio, "%.4f"), formatOptional(report.VolumeCorrelation, "%.4f"))
+	fmt.Fprintf(writer, "divergences (> %.4f): %d\n", report.Threshold, len(report.Divergences))
+
+	for i, divergence := range report.Divergences {
+		if i >= report.MaxDivergences {
+			fmt.Fprintf(writer, "... %d more\n", len(report.Divergences)-i)
+			break
+		}
+		fmt.Fprintf(writer, "divergence ts=%d left=%g right=%g rel=%.6f\n",
+			divergence.Timestamp, divergence.LeftClose, divergence.RightClose, divergence.RelDiff)
+	}
+}
+
+// csvTable lists every divergence.
+func (report reconcileReport) csvTable() ([]string, [][]string) {
+	header := []string{"timestamp", "left_close", "right_close", "rel_diff"}
+	records := make([][]string, 0, len(report.Divergences))
+	for _, divergence := range report.Divergences {
+		records = append(records, []string{
+			csvInt(divergence.Timestamp), csvFloat(divergence.LeftClose), csvFloat(divergence.RightClose), csvFloat(divergence.RelDiff),
+		})
+	}
+	return header, records
+}
+
+// finiteOrNil maps the NaN of an undefined statistic to nil (JSON cannot encode NaN).
+func finiteOrNil(value float64) *float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	return &value
+}
+
+func formatOptional(value *float64, format string) string {
+	if value == nil {
+		return "n/a"
+	}
+	return fmt.Sprintf(format, *value)
+}
+
+func init() {
+	reconcileCommand.Flags().StringVar(&reconcileLeft, "left", "binance:BTCUSDT", "First series as exchange:symbol")
+	reconcileCommand.Flags().StringVar(&reconcileRight, "right", "", "Second series as exchange:symbol (e.g. coinbase:BTC-USD)")
+	reconcileCommand.Flags().StringVar(&reconcileTimeframe, "timeframe", "4h", "Timeframe (e.g. 4h)")
+	reconcileCommand.Flags().Float64Var(&reconcileThreshold, "threshold", 0.005, "Relative close difference that counts as a divergence (0.005 = 0.5%)")
+	reconcileCommand.Flags().IntVar(&reconcileMaxDivergences, "max-divergences", 20, "Print at most this many divergences")
+	reconcileCommand.Flags().BoolVar(&reconcileFailOnDivergence, "fail-on-divergence", false, "Exit non-zero when any bar diverges (for CI)")
+}
+
+func parseSeriesKey(value string) (exchangeName string, symbol string, err error) {
+	exchangeName, symbol, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found || exchangeName == "" || symbol == "" {
+		return "", "", fmt.Errorf("want exchange:symbol, got %q", value)
+	}
+	return exchangeName, symbol, nil
+}
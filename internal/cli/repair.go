@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/exchange"
+	"btc-4h-prediction-model/internal/ingest"
+	"btc-4h-prediction-model/internal/store"
+)
+
+var repairSymbol string
+var repairTimeframe string
+var repairWeightBudget int
+var repairBaseURL string
+var repairRecordKnown bool
+
+var repairCommand = &cobra.Command{
+	Use:   "repair",
+	Short: "Refetch candle gaps from the exchange and record the ones it cannot fill as known gaps",
+	RunE: func(command *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		database, err := store.OpenSQLite(databasePath)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		timeframe, err := candles.ParseTimeframe(repairTimeframe)
+		if err != nil {
+			return err
+		}
+		repairTimeframe = timeframe.String()
+
+		source, err := exchange.NewCandleSource(exchangeName, exchange.SourceOptions{
+			WeightBudget: repairWeightBudget,
+			BaseURL:      repairBaseURL,
+		})
+		if err != nil {
+			return err
+		}
+		if !exchange.SupportsTimeframe(source, repairTimeframe) {
+			return fmt.Errorf("%s does not support timeframe %q", source.Name(), repairTimeframe)
+		}
+
+		repairResult, err := ingest.RepairGaps(ctx, database, source, repairSymbol, timeframe, repairRecordKnown)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("db:", databasePath)
+		fmt.Println("exchange:", exchangeName)
+		fmt.Println("symbol:", repairSymbol)
+		fmt.Println("timeframe:", repairTimeframe)
+		fmt.Println("gaps found:", repairResult.GapsFound)
+		fmt.Println("candles upserted:", repairResult.Upserted)
+		fmt.Println("unfillable gaps:", len(repairResult.Unfillable))
+		for i, gap := range repairResult.Unfillable {
+			fmt.Printf("unfillable %d: expected=%d actual=%d missingIntervals=%d\n", i+1, gap.ExpectedTS, gap.ActualTS, gap.Missing)
+		}
+		if repairRecordKnown {
+			fmt.Println("recorded as known gaps:", len(repairResult.Unfillable))
+		}
+		fmt.Println("misaligned (not repairable):", len(repairResult.Misaligned))
+
+		return nil
+	},
+}
+
+func init() {
+	repairCommand.Flags().StringVar(&repairSymbol, "symbol", "BTCUSDT", "Symbol (e.g. BTCUSDT)")
+	repairCommand.Flags().StringVar(&repairTimeframe, "timeframe", "4h", "Timeframe (e.g. 4h)")
+	repairCommand.Flags().IntVar(&repairWeightBudget, "weight-budget", 0, "Request weight to spend per minute on weight-metered exchanges like Binance (0 = exchange default, -1 disables client-side limiting)")
+	repairCommand.Flags().StringVar(&repairBaseURL, "base-url", "", "Override the exchange API base URL (e.g. a local mock server)")
+	repairCommand.Flags().BoolVar(&repairRecordKnown, "record-known", true, "Record gaps the exchange cannot fill in known_gaps so validate stops flagging them")
+}
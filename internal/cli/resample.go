@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/store"
+)
+
+var resampleSymbol string
+var resampleFromTimeframe string
+var resampleToTimeframe string
+var resampleIncludePartial bool
+
+var resampleCommand = &cobra.Command{
+	Use:   "resample",
+	Short: "Aggregate stored lower-timeframe candles into a higher timeframe (refused over exchange-fetched bars)",
+	RunE: func(command *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		db, err := store.OpenSQLite(databasePath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		candleSeries, err := store.LoadCandlesOrdered(ctx, db, exchangeName, resampleSymbol, resampleFromTimeframe)
+		if err != nil {
+			return err
+		}
+		if len(candleSeries) == 0 {
+			return fmt.Errorf("no candles found for %s %s", resampleSymbol, resampleFromTimeframe)
+		}
+
+		result, err := candles.Resample(candleSeries, resampleToTimeframe, resampleIncludePartial)
+		if err != nil {
+			return err
+		}
+
+		if err := upsertResampled(ctx, db, exchangeName, resampleSymbol, result.Candles); err != nil {
+			return err
+		}
+
+		partial := len(result.PartialBuckets)
+		for _, candle := range result.Candles {
+			if !candle.IsFinal {
+				partial++
+			}
+		}
+
+		fmt.Println("db:", databasePath)
+		fmt.Println("exchange:", exchangeName)
+		fmt.Println("symbol:", resampleSymbol)
+		fmt.Println("from:", resampleFromTimeframe)
+		fmt.Println("to:", resampleToTimeframe)
+		fmt.Println("source candles:", len(candleSeries))
+		fmt.Println("upserted:", len(result.Candles))
+		if resampleIncludePartial {
+			fmt.Println("partial (non-final):", partial)
+		} else {
+			fmt.Println("partial (not written, see --include-partial):", partial)
+		}
+		fmt.Println("skipped (missing constituents):", len(result.SkippedBuckets))
+
+		maxSkippedToPrint := 5
+		for i, bucket := range result.SkippedBuckets {
+			if i >= maxSkippedToPrint {
+				break
+			}
+			fmt.Printf("skipped %d: ts=%d\n", i+1, bucket)
+		}
+
+		return nil
+	},
+}
+
+// upsertResampled stores resampled bars under the source's exchange, where features and labels
+// look for them. It refuses a target series that holds exchange-fetched bars, which resampled
+// ones would silently overwrite.
+func upsertResampled(ctx context.Context, db *sql.DB, exchange string, symbol string, resampled []candles.Candle) error {
+	if len(resampled) == 0 {
+		return nil
+	}
+	timeframe := resampled[0].Timeframe
+	fetched, err := store.CountExchangeCandles(ctx, db, exchange, symbol, timeframe)
+	if err != nil {
+		return err
+	}
+	if fetched > 0 {
+		return fmt.Errorf("%s %s %s already holds %d candles fetched from the exchange; resampled bars would overwrite them", exchange, symbol, timeframe, fetched)
+	}
+	return store.UpsertCandles(ctx, db, resampled)
+}
+
+func init() {
+	resampleCommand.Flags().StringVar(&resampleSymbol, "symbol", "BTCUSDT", "Symbol (e.g. BTCUSDT)")
+	resampleCommand.Flags().StringVar(&resampleFromTimeframe, "from", "1h", "Source timeframe stored in the database")
+	resampleCommand.Flags().StringVar(&resampleToTimeframe, "to", "4h", "Target timeframe (a larger multiple of --from)")
+	resampleCommand.Flags().BoolVar(&resampleIncludePartial, "include-partial", false, "Also write the trailing target bar that is still filling up, as non-final (never replaces a final bar)")
+}
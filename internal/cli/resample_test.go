@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/store"
+)
+
+func TestUpsertResampledKeepsExchangeBars(t *testing.T) {
+	ctx := context.Background()
+	const start = 1709251200000
+	const hour = 60 * 60 * 1000
+	db := openTestDatabase(t)
+
+	var hourly []candles.Candle
+	for i := int64(0); i < 8; i++ {
+		hourly = append(hourly, candles.Candle{
+			Exchange: "test", Symbol: "BTCUSDT", Timeframe: "1h", Timestamp: start + i*hour,
+			Open: 100, High: 102, Low: 98, Close: 101, Volume: 2, CloseTime: start + (i+1)*hour - 1, IsFinal: true,
+		})
+	}
+	result, err := candles.Resample(hourly, "4H", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Candles) != 2 {
+		t.Fatalf("resampled %d bars, want 2", len(result.Candles))
+	}
+
+	// An empty target series, then one that holds only resampled bars, both take the write
+	for run := 0; run < 2; run++ {
+		if err := upsertResampled(ctx, db, "test", "BTCUSDT", result.Candles); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+	}
+	stored, err := store.LoadCandlesOrdered(ctx, db, "test", "BTCUSDT", "4h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 || stored[0].ResampledFrom != "1h" || stored[0].Volume != 8 {
+		t.Fatalf("stored %+v, want two 4h bars resampled from 1h", stored)
+	}
+
+	// An exchange bar replaces a resampled one and clears its marker; resample then backs off
+	exchangeBar := testCandle(start)
+	if err := store.UpsertCandles(ctx, db, []candles.Candle{exchangeBar}); err != nil {
+		t.Fatal(err)
+	}
+	err = upsertResampled(ctx, db, "test", "BTCUSDT", result.Candles)
+	if err == nil || !strings.Contains(err.Error(), "1 candles fetched from the exchange") {
+		t.Fatalf("err = %v, want a refusal to overwrite the exchange bar", err)
+	}
+	stored, err = store.LoadCandlesOrdered(ctx, db, "test", "BTCUSDT", "4h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored[0].ResampledFrom != "" || stored[0].Volume != exchangeBar.Volume {
+		t.Fatalf("exchange bar became %+v", stored[0])
+	}
+}
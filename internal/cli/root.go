@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -10,28 +11,67 @@ import (
 var rootCommand = &cobra.Command{
 	Use:   "quant",
 	Short: "Quantitative research CLI",
+	// Execute prints errors to stderr itself, after any report on stdout
+	SilenceErrors: true,
+	PersistentPreRunE: func(command *cobra.Command, args []string) error {
+		return validateOutputFormat(command)
+	},
 }
 
 var databasePath string
+var exchangeName string
 
 func Execute() {
+	os.Exit(execute(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// execute runs one command line and returns the process exit code. Reports go to stdout and
+// errors to stderr, so --output json/csv stays parseable when a command fails.
+func execute(args []string, stdout io.Writer, stderr io.Writer) int {
+	reportWriter = stdout
+	rootCommand.SetErr(stderr)
+	rootCommand.SetArgs(args)
+	if err := rootCommand.Execute(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func init() {
 	rootCommand.PersistentFlags().StringVar(
 		&databasePath,
 		"db",
 		"btcqd.sqlite",
 		"Path to SQLite database file",
 	)
+	rootCommand.PersistentFlags().StringVar(
+		&exchangeName,
+		"exchange",
+		"binance",
+		"Exchange name (candle source for ingest, series key for everything else)",
+	)
+	rootCommand.PersistentFlags().StringVar(
+		&outputFormat,
+		"output",
+		outputText,
+		"Output format for ingest, validate, reconcile, features, labels, train, confidence, paper and run: text, json or csv",
+	)
 
+	rootCommand.AddCommand(migrateCommand)
 	rootCommand.AddCommand(ingestCommand)
+	rootCommand.AddCommand(importCommand)
+	rootCommand.AddCommand(exportCommand)
+	rootCommand.AddCommand(streamCommand)
+	rootCommand.AddCommand(futuresCommand)
+	rootCommand.AddCommand(resampleCommand)
 	rootCommand.AddCommand(validateCommand)
+	rootCommand.AddCommand(repairCommand)
+	rootCommand.AddCommand(reconcileCommand)
 	rootCommand.AddCommand(featuresCommand)
 	rootCommand.AddCommand(labelsCommand)
 	rootCommand.AddCommand(trainCommand)
 	rootCommand.AddCommand(confidenceCommand)
 	rootCommand.AddCommand(paperCommand)
-
-	if err := rootCommand.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	rootCommand.AddCommand(runCommand)
 }
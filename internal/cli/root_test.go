@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/store"
+)
+
+func TestFailingCommandKeepsJSONOutputParseable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sqlite")
+	db, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Three 4h bars with the middle one missing: a gap warning
+	const fourHours = 4 * 60 * 60 * 1000
+	var series []candles.Candle
+	for _, open := range []int64{1709251200000, 1709251200000 + 2*fourHours} {
+		series = append(series, candles.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Timeframe: "4h", Timestamp: open,
+			Open: 100, High: 101, Low: 99, Close: 100.5, Volume: 1, CloseTime: open + fourHours - 1, IsFinal: true,
+		})
+	}
+	if err := store.UpsertCandles(context.Background(), db, series); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := execute([]string{"--db", path, "--output", "json", "validate", "--symbol", "BTCUSDT", "--timeframe", "4h", "--fail-on", "warning"}, &stdout, &stderr)
+	t.Cleanup(func() { outputFormat, validateFailOn, reportWriter = outputText, "none", os.Stdout })
+
+	if code == 0 {
+		t.Fatalf("exit code 0, want non-zero for a failed audit")
+	}
+	var report map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("stdout is not a JSON report: %v\n%s", err, stdout.String())
+	}
+	if report["symbol"] != "BTCUSDT" {
+		t.Errorf("report symbol = %v, want BTCUSDT", report["symbol"])
+	}
+	if !strings.Contains(stderr.String(), "validation failed") {
+		t.Errorf("stderr = %q, want the validation error", stderr.String())
+	}
+}
@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"btc-4h-prediction-model/internal/config"
+	"btc-4h-prediction-model/internal/features"
+	"btc-4h-prediction-model/internal/ingest"
+	"btc-4h-prediction-model/internal/model"
+	"btc-4h-prediction-model/internal/store"
+)
+
+var runConfigPath string
+
+// Pipeline stages in dependency order.
+var runStages = []string{"ingest", "validate", "features", "labels", "train", "paper"}
+
+var runCommand = &cobra.Command{
+	Use:         "run",
+	Short:       "Run ingest → validate → features → labels → train → paper for every combination in a pipeline config",
+	Annotations: reportOutput,
+	RunE: func(command *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		pipeline, err := config.LoadPipeline(runConfigPath)
+		if err != nil {
+			return err
+		}
+		failOn, err := ingest.ParseSeverity(pipeline.Validate.FailOn)
+		if err != nil {
+			return fmt.Errorf("%s: validate.fail_on: %w", runConfigPath, err)
+		}
+		specs, err := features.ParseSpecs(pipeline.Features.Registry)
+		if err != nil {
+			return fmt.Errorf("%s: features.registry: %w", runConfigPath, err)
+		}
+		if pipeline.DB == "" {
+			pipeline.DB = databasePath
+		}
+
+		database, err := store.OpenSQLite(pipeline.DB)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		report := runReport{
+			Config:       runConfigPath,
+			DB:           pipeline.DB,
+			Combinations: len(pipeline.Combinations()),
+			Stages:       []runStageResult{},
+		}
+		for _, combination := range pipeline.Combinations() {
+			for _, stage := range runStages {
+				stageOutput, err := runPipelineStage(ctx, database, pipeline, failOn, specs, combination, stage)
+				result := runStageResult{
+					Exchange:  combination.Exchange,
+					Symbol:    combination.Symbol,
+					Timeframe: combination.Timeframe,
+					Stage:     stage,
+					Status:    "ok",
+					Report:    stageOutput,
+				}
+				if err != nil {
+					result.Status = "failed"
+					result.Error = err.Error()
+				}
+				report.Stages = append(report.Stages, result)
+
+				if err != nil {
+					report.Failed = &report.Stages[len(report.Stages)-1]
+					if writeErr := writeReport(report); writeErr != nil {
+						return writeErr
+					}
+					// A failed stage is a result, not a usage mistake
+					command.SilenceUsage = true
+					return fmt.Errorf("pipeline failed at %s for %s: %w", stage, combination, err)
+				}
+			}
+			report.Completed++
+		}
+
+		return writeReport(report)
+	},
+}
+
+// runPipelineStage runs one stage for one combination. The stage report is returned even when
+// the stage fails on its result (validate over fail_on) so the run report can show it.
+func runPipelineStage(
+	ctx context.Context,
+	database *sql.DB,
+	pipeline config.Pipeline,
+	failOn ingest.Severity,
+	specs []features.Spec,
+	combination config.Combination,
+	stage string,
+) (commandReport, error) {
+	switch stage {
+	case "ingest":
+		return stageReport(runIngest(ctx, database, ingestOptions{
+			DB:           pipeline.DB,
+			Exchange:     combination.Exchange,
+			Symbol:       combination.Symbol,
+			Timeframe:    combination.Timeframe,
+			Days:         *pipeline.Ingest.Days,
+			Incremental:  pipeline.Ingest.Incremental,
+			BackfillGaps: pipeline.Ingest.BackfillGaps,
+			WeightBudget: pipeline.Ingest.WeightBudget,
+			BaseURL:      pipeline.Ingest.BaseURL,
+		}))
+	case "validate":
+		report, err := runValidate(ctx, database, validateOptions{
+			DB:         pipeline.DB,
+			Exchange:   combination.Exchange,
+			Symbol:     combination.Symbol,
+			Timeframe:  combination.Timeframe,
+			JumpSigmas: *pipeline.Validate.JumpSigmas,
+			JumpWindow: *pipeline.Validate.JumpWindow,
+			MaxIssues:  20,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return report, report.failure(failOn)
+	case "features":
+		return stageReport(runFeatures(ctx, database, featuresOptions{
+			DB:                 pipeline.DB,
+			Exchange:           combination.Exchange,
+			Symbol:             combination.Symbol,
+			Timeframe:          combination.Timeframe,
+			FuturesExchange:    pipeline.Features.FuturesExchange,
+			FuturesSymbol:      pipeline.Features.FuturesSymbol,
+			OpenInterestPeriod: pipeline.Features.OpenInterestPeriod,
+			Specs:              specs,
+			Full:               pipeline.Features.Full,
+		}))
+	case "labels":
+		return stageReport(runLabels(ctx, database, labelsOptions{
+			DB:         pipeline.DB,
+			Exchange:   combination.Exchange,
+			Symbol:     combination.Symbol,
+			Timeframe:  combination.Timeframe,
+			ThresholdB: *pipeline.Labels.ThresholdB,
+		}))
+	case "train":
+		return stageReport(runTrain(ctx, database, trainOptions{
+			DB:        pipeline.DB,
+			Exchange:  combination.Exchange,
+			Symbol:    combination.Symbol,
+			Timeframe: combination.Timeframe,
+			Features:  specs,
+			Config: model.TrainConfig{
+				Folds:        pipeline.Train.Folds,
+				Epochs:       pipeline.Train.Epochs,
+				LearningRate: pipeline.Train.LearningRate,
+				L2Lambda:     *pipeline.Train.L2Lambda,
+				Seed:         *pipeline.Train.Seed,
+			},
+			WritePredictions: *pipeline.Train.WritePredictions,
+		}))
+	case "paper":
+		// Equity CSV names carry symbol and timeframe only, so keep exchanges apart by directory
+		outDir := *pipeline.Paper.OutDir
+		if outDir != "" {
+			outDir = filepath.Join(outDir, combination.Exchange)
+		}
+		return stageReport(runPaper(ctx, database, paperOptions{
+			DB:         pipeline.DB,
+			Exchange:   combination.Exchange,
+			Symbol:     combination.Symbol,
+			Timeframe:  combination.Timeframe,
+			ModelName:  pipeline.Paper.Model,
+			Thresholds: pipeline.Paper.Thresholds,
+			Fee:        *pipeline.Paper.Fee,
+			Slippage:   pipeline.Paper.Slippage,
+			OutDir:     outDir,
+		}))
+	default:
+		return nil, fmt.Errorf("unknown stage %q", stage)
+	}
+}
+
+// stageReport drops the zero-value report of a failed stage.
+func stageReport[T commandReport](report T, err error) (commandReport, error) {
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+type runReport struct {
+	Config string `json:"config"`
+	DB     string `json:"db"`
+
+	Combinations int `json:"combinations"`
+	Completed    int `json:"completed"`
+
+	// Every stage that ran, in order; the last one is the failure when Failed is set
+	Stages []runStageResult `json:"stages"`
+	Failed *runStageResult  `json:"failed,omitempty"`
+}
+
+type runStageResult struct {
+	Exchange  string `json:"exchange"`
+	Symbol    string `json:"symbol"`
+	Timeframe string `json:"timeframe"`
+	Stage     string `json:"stage"`
+	Status    string `json:"status"` // ok or failed
+	Error     string `json:"error,omitempty"`
+
+	Report commandReport `json:"report,omitempty"` // nil when the stage failed before producing one
+}
+
+func (report runReport) writeText(writer io.Writer) {
+	fmt.Fprintln(writer, "config:", report.Config)
+	fmt.Fprintln(writer, "db:", report.DB)
+
+	for _, result := range report.Stages {
+		fmt.Fprintf(writer, "\n== %s %s %s: %s (%s)\n", result.Exchange, result.Symbol, result.Timeframe, result.Stage, result.Status)
+		if result.Report != nil {
+			result.Report.writeText(writer)
+		}
+	}
+
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "combinations completed: %d/%d\n", report.Completed, report.Combinations)
+	if report.Failed != nil {
+		fmt.Fprintf(writer, "FAILED stage: %s\n", report.Failed.Stage)
+		fmt.Fprintf(writer, "FAILED combination: %s %s %s\n", report.Failed.Exchange, report.Failed.Symbol, report.Failed.Timeframe)
+		fmt.Fprintf(writer, "FAILED error: %s\n", report.Failed.Error)
+	}
+}
+
+// csvTable has one row per stage that ran; stage details are in text or json output.
+func (report runReport) csvTable() ([]string, [][]string) {
+	header := []string{"exchange", "symbol", "timeframe", "stage", "status", "error"}
+	records := make([][]string, 0, len(report.Stages))
+	for _, result := range report.Stages {
+		records = append(records, []string{result.Exchange, result.Symbol, result.Timeframe, result.Stage, result.Status, result.Error})
+	}
+	return header, records
+}
+
+func init() {
+	runCommand.Flags().StringVar(&runConfigPath, "config", "pipeline.yaml", "Pipeline config (YAML) declaring exchanges, symbols, timeframes and stage settings")
+}
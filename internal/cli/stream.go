@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/exchange"
+	"btc-4h-prediction-model/internal/store"
+)
+
+var streamSymbols string
+var streamTimeframes string
+var streamURL string
+var streamBaseURL string
+var streamCatchUpDays int
+
+var streamCommand = &cobra.Command{
+	Use:   "stream",
+	Short: "Stream Binance klines over WebSocket and upsert candles as they close",
+	RunE: func(command *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		market, ok := exchange.BinanceMarketByName(exchangeName)
+		if !ok {
+			return fmt.Errorf("stream only supports Binance markets, got exchange %q", exchangeName)
+		}
+		if streamCatchUpDays <= 0 {
+			return fmt.Errorf("--days must be > 0")
+		}
+
+		subscriptions, err := parseStreamSubscriptions(streamSymbols, streamTimeframes)
+		if err != nil {
+			return err
+		}
+
+		database, err := store.OpenSQLite(databasePath)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		source, err := exchange.NewCandleSource(market.Name, exchange.SourceOptions{BaseURL: streamBaseURL})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("db:", databasePath)
+		fmt.Println("exchange:", market.Name)
+		for _, subscription := range subscriptions {
+			fmt.Println("subscribed:", subscription.Symbol, subscription.Timeframe)
+		}
+
+		stream := exchange.BinanceKlineStream{
+			Market:        market,
+			StreamURL:     streamURL,
+			Subscriptions: subscriptions,
+			Retry:         exchange.DefaultRetryPolicy(),
+
+			OnConnect: func(ctx context.Context, reconnect bool) error {
+				// Anything that closed while we were disconnected only exists on REST now
+				for _, subscription := range subscriptions {
+					upserted, err := catchUpSeries(ctx, database, source, subscription.Symbol, subscription.Timeframe, streamCatchUpDays)
+					if err != nil {
+						return fmt.Errorf("rest backfill %s %s: %w", subscription.Symbol, subscription.Timeframe, err)
+					}
+					fmt.Printf("connected: reconnect=%t backfill %s %s upserted=%d\n", reconnect, subscription.Symbol, subscription.Timeframe, upserted)
+				}
+				return nil
+			},
+			OnClosedCandle: func(ctx context.Context, candle candles.Candle) error {
+				if err := store.UpsertCandles(ctx, database, []candles.Candle{candle}); err != nil {
+					// The database, not the connection, is broken: reconnecting will not help
+					return exchange.StreamFatal(err)
+				}
+				fmt.Printf("closed: %s %s ts=%d close=%v volume=%v\n", candle.Symbol, candle.Timeframe, candle.Timestamp, candle.Close, candle.Volume)
+				return nil
+			},
+			OnDisconnect: func(err error, retryIn time.Duration) {
+				fmt.Printf("disconnected: %v (retry in %s)\n", err, retryIn.Round(time.Millisecond))
+			},
+			OnBadMessage: func(body []byte, err error) {
+				fmt.Printf("skipped message: %v\n", err)
+			},
+		}
+
+		err = stream.Run(ctx)
+		if errors.Is(err, context.Canceled) {
+			fmt.Println("stopped")
+			return nil
+		}
+		return err
+	},
+}
+
+func init() {
+	streamCommand.Flags().StringVar(&streamSymbols, "symbols", "BTCUSDT", "Comma-separated symbols (e.g. BTCUSDT,ETHUSDT)")
+	streamCommand.Flags().StringVar(&streamTimeframes, "timeframes", "4h", "Comma-separated timeframes subscribed for every symbol")
+	streamCommand.Flags().StringVar(&streamURL, "stream-url", "", "Override the combined-stream WebSocket URL (e.g. a local stand-in server)")
+	streamCommand.Flags().StringVar(&streamBaseURL, "base-url", "", "Override the REST base URL used for reconnect backfills")
+	streamCommand.Flags().IntVar(&streamCatchUpDays, "days", 30, "REST backfill lookback in days for series with no stored candles")
+}
+
+func parseStreamSubscriptions(symbols string, timeframes string) ([]exchange.StreamSubscription, error) {
+	var subscriptions []exchange.StreamSubscription
+	for _, symbol := range strings.Split(symbols, ",") {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" {
+			continue
+		}
+		for _, timeframe := range strings.Split(timeframes, ",") {
+			timeframe = strings.TrimSpace(timeframe)
+			if timeframe == "" {
+				continue
+			}
+			parsed, err := candles.ParseTimeframe(timeframe)
+			if err != nil {
+				return nil, err
+			}
+			subscriptions = append(subscriptions, exchange.StreamSubscription{Symbol: symbol, Timeframe: parsed.String()})
+		}
+	}
+	if len(subscriptions) == 0 {
+		return nil, fmt.Errorf("no symbols/timeframes to subscribe to")
+	}
+	return subscriptions, nil
+}
+
+// catchUpSeries fetches everything after the newest closed candle of a series (or the last
+// lookbackDays when the series is empty) and upserts it.
+func catchUpSeries(ctx context.Context, database *sql.DB, source exchange.CandleSource, symbol string, timeframe string, lookbackDays int) (int, error) {
+	parsedTimeframe, err := candles.ParseTimeframe(timeframe)
+	if err != nil {
+		return 0, err
+	}
+	timeframe = parsedTimeframe.String()
+
+	startTimeMillis := time.Now().UTC().Add(time.Duration(-lookbackDays) * 24 * time.Hour).UnixMilli()
+	latestTimestamp, found, err := store.LatestFinalCandleTimestamp(ctx, database, source.Name(), symbol, timeframe)
+	if err != nil {
+		return 0, err
+	}
+	if found {
+		startTimeMillis = parsedTimeframe.Next(latestTimestamp)
+	}
+
+	fetched, err := source.FetchCandles(ctx, symbol, timeframe, startTimeMillis, 0)
+	if err != nil {
+		return 0, err
+	}
+	if err := store.UpsertCandles(ctx, database, fetched); err != nil {
+		return 0, err
+	}
+	return len(fetched), nil
+}
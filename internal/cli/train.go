@@ -2,10 +2,14 @@ package cli
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"btc-4h-prediction-model/internal/features"
 	"btc-4h-prediction-model/internal/model"
 	"btc-4h-prediction-model/internal/store"
 )
@@ -13,6 +17,7 @@ import (
 var trainWritePredictions bool
 var trainSymbol string
 var trainTimeframe string
+var trainFeatures []string
 
 var trainFolds int
 var trainEpochs int
@@ -21,8 +26,9 @@ var trainL2Lambda float64
 var trainSeed int64
 
 var trainCommand = &cobra.Command{
-	Use:   "train",
-	Short: "Walk-forward baselines + multinomial logistic regression on dataset view",
+	Use:         "train",
+	Short:       "Walk-forward baselines + multinomial logistic regression on dataset view",
+	Annotations: reportOutput,
 	RunE: func(command *cobra.Command, args []string) error {
 		ctx := context.Background()
 
@@ -32,45 +38,188 @@ var trainCommand = &cobra.Command{
 		}
 		defer db.Close()
 
-		datasetRows, err := model.LoadDatasetOrdered(ctx, db, "binance", trainSymbol, trainTimeframe)
+		specs, err := features.ParseSpecs(trainFeatures)
 		if err != nil {
 			return err
 		}
 
-		fmt.Println("db:", databasePath)
-		fmt.Println("exchange: binance")
-		fmt.Println("symbol:", trainSymbol)
-		fmt.Println("timeframe:", trainTimeframe)
-		fmt.Println("dataset rows:", len(datasetRows))
-
-		result, err := model.EvaluateWalkForward(datasetRows, model.TrainConfig{
-			Folds:        trainFolds,
-			Epochs:       trainEpochs,
-			LearningRate: trainLearningRate,
-			L2Lambda:     trainL2Lambda,
-			Seed:         trainSeed,
+		report, err := runTrain(ctx, db, trainOptions{
+			DB:        databasePath,
+			Exchange:  exchangeName,
+			Symbol:    trainSymbol,
+			Timeframe: trainTimeframe,
+			Features:  specs,
+			Config: model.TrainConfig{
+				Folds:        trainFolds,
+				Epochs:       trainEpochs,
+				LearningRate: trainLearningRate,
+				L2Lambda:     trainL2Lambda,
+				Seed:         trainSeed,
+			},
+			WritePredictions: trainWritePredictions,
 		})
 		if err != nil {
 			return err
 		}
+		return writeReport(report)
+	},
+}
+
+// trainOptions are the train flags; quant run fills them from the pipeline config.
+type trainOptions struct {
+	DB               string // database path, shown in the report
+	Exchange         string
+	Symbol           string
+	Timeframe        string
+	Features         []features.Spec // registry features added after the dataset-view columns
+	Config           model.TrainConfig
+	WritePredictions bool
+}
 
-		fmt.Println("always NO_TRADE:", result.BaselineNoTrade.SummaryString())
-		fmt.Println("random baseline:", result.BaselineRandom.SummaryString())
-		fmt.Println("logreg softmax:", result.LogReg.SummaryString())
-		if trainWritePredictions {
-			if err := store.UpsertPredictions(ctx, db, result.LogRegPredictions); err != nil {
-				return err
+func runTrain(ctx context.Context, db *sql.DB, options trainOptions) (trainReport, error) {
+	datasetRows, err := model.LoadDatasetOrdered(ctx, db, options.Exchange, options.Symbol, options.Timeframe)
+	if err != nil {
+		return trainReport{}, err
+	}
+	if len(options.Features) > 0 {
+		columns := make([]map[int64]float64, len(options.Features))
+		for i, spec := range options.Features {
+			columns[i], err = store.LoadFeatureValues(ctx, db, options.Exchange, options.Symbol, options.Timeframe, spec)
+			if err != nil {
+				return trainReport{}, err
 			}
-			fmt.Println("predictions upserted:", len(result.LogRegPredictions))
 		}
+		datasetRows = model.AttachExtraFeatures(datasetRows, columns)
+		if len(datasetRows) == 0 {
+			return trainReport{}, fmt.Errorf("dataset empty after joining %s (run quant features with the same --feature specs)", strings.Join(specStrings(options.Features), " "))
+		}
+	}
 
-		return nil
-	},
+	result, err := model.EvaluateWalkForward(datasetRows, options.Config)
+	if err != nil {
+		return trainReport{}, err
+	}
+
+	report := trainReport{
+		DB:          options.DB,
+		Exchange:    options.Exchange,
+		Symbol:      options.Symbol,
+		Timeframe:   options.Timeframe,
+		DatasetRows: len(datasetRows),
+		Features:    specStrings(options.Features),
+		Models: []confusionMatrixReport{
+			newConfusionMatrixReport("always_no_trade", "always NO_TRADE", result.BaselineNoTrade),
+			newConfusionMatrixReport("random_baseline", "random baseline", result.BaselineRandom),
+			newConfusionMatrixReport("logreg_softmax", "logreg softmax", result.LogReg),
+		},
+		WritePredictions: options.WritePredictions,
+	}
+	if options.WritePredictions {
+		if err := store.UpsertPredictions(ctx, db, result.LogRegPredictions); err != nil {
+			return trainReport{}, err
+		}
+		report.PredictionsUpserted = len(result.LogRegPredictions)
+	}
+	return report, nil
+}
+
+type trainReport struct {
+	DB        string `json:"db"`
+	Exchange  string `json:"exchange"`
+	Symbol    string `json:"symbol"`
+	Timeframe string `json:"timeframe"`
+
+	DatasetRows int                     `json:"dataset_rows"`
+	Features    []string                `json:"registry_features"`
+	Models      []confusionMatrixReport `json:"models"`
+
+	WritePredictions    bool `json:"write_predictions"`
+	PredictionsUpserted int  `json:"predictions_upserted"`
+}
+
+// confusionMatrixReport is the out-of-sample confusion matrix of one model over all folds.
+type confusionMatrixReport struct {
+	Model string `json:"model"`
+	label string
+
+	Accuracy float64 `json:"accuracy"`
+	// Classes orders both matrix axes; rows = actual, columns = predicted
+	Classes   []string           `json:"classes"`
+	Matrix    [3][3]int          `json:"matrix"`
+	Precision map[string]float64 `json:"precision"`
+	Recall    map[string]float64 `json:"recall"`
+
+	matrix model.ConfusionMatrix
+}
+
+func newConfusionMatrixReport(name string, label string, matrix model.ConfusionMatrix) confusionMatrixReport {
+	report := confusionMatrixReport{
+		Model:     name,
+		label:     label,
+		Accuracy:  matrix.Accuracy(),
+		Matrix:    matrix.M,
+		Precision: map[string]float64{},
+		Recall:    map[string]float64{},
+		matrix:    matrix,
+	}
+	for _, class := range []model.Class{model.ClassUp, model.ClassDown, model.ClassNoTrade} {
+		report.Classes = append(report.Classes, class.String())
+		report.Precision[class.String()], report.Recall[class.String()] = matrix.PrecisionRecall(class)
+	}
+	return report
+}
+
+func (report trainReport) writeText(writer io.Writer) {
+	fmt.Fprintln(writer, "db:", report.DB)
+	fmt.Fprintln(writer, "exchange:", report.Exchange)
+	fmt.Fprintln(writer, "symbol:", report.Symbol)
+	fmt.Fprintln(writer, "timeframe:", report.Timeframe)
+	fmt.Fprintln(writer, "dataset rows:", report.DatasetRows)
+	if len(report.Features) > 0 {
+		fmt.Fprintln(writer, "registry features:", strings.Join(report.Features, " "))
+	}
+
+	for _, modelReport := range report.Models {
+		fmt.Fprintf(writer, "%s: %s\n", modelReport.label, modelReport.matrix.SummaryString())
+	}
+	if report.WritePredictions {
+		fmt.Fprintln(writer, "predictions upserted:", report.PredictionsUpserted)
+	}
+}
+
+// csvTable has one row per model with the confusion matrix flattened as cm_<actual>_<predicted>.
+func (report trainReport) csvTable() ([]string, [][]string) {
+	header := []string{"model", "accuracy"}
+	classes := []model.Class{model.ClassUp, model.ClassDown, model.ClassNoTrade}
+	for _, class := range classes {
+		header = append(header, "precision_"+class.String(), "recall_"+class.String())
+	}
+	for _, actual := range classes {
+		for _, predicted := range classes {
+			header = append(header, "cm_"+actual.String()+"_"+predicted.String())
+		}
+	}
+
+	var records [][]string
+	for _, modelReport := range report.Models {
+		record := []string{modelReport.Model, csvFloat(modelReport.Accuracy)}
+		for _, class := range classes {
+			record = append(record, csvFloat(modelReport.Precision[class.String()]), csvFloat(modelReport.Recall[class.String()]))
+		}
+		for _, actual := range classes {
+			for _, predicted := range classes {
+				record = append(record, csvInt(modelReport.Matrix[actual][predicted]))
+			}
+		}
+		records = append(records, record)
+	}
+	return header, records
 }
 
 func init() {
 	trainCommand.Flags().StringVar(&trainSymbol, "symbol", "BTCUSDT", "Symbol (e.g. BTCUSDT)")
 	trainCommand.Flags().StringVar(&trainTimeframe, "timeframe", "4h", "Timeframe (e.g. 4h)")
+	trainCommand.Flags().StringArrayVar(&trainFeatures, "feature", nil, "Registry feature to add as a model input, e.g. ema(20) (repeatable; computed by quant features --feature)")
 
 	trainCommand.Flags().IntVar(&trainFolds, "folds", 5, "Number of walk-forward folds")
 	trainCommand.Flags().IntVar(&trainEpochs, "epochs", 500, "Training epochs for logistic regression")
@@ -1,73 +1,176 @@
 package cli
 
 import (
-	"btc-4h-prediction-model/internal/candles"
 	"context"
+	"database/sql"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 
+	"btc-4h-prediction-model/internal/candles"
 	"btc-4h-prediction-model/internal/ingest"
 	"btc-4h-prediction-model/internal/store"
 )
 
 var validateSymbol string
 var validateTimeframe string
+var validateFailOn string
+var validateJumpSigmas float64
+var validateJumpWindow int
+var validateMaxIssues int
 
 var validateCommand = &cobra.Command{
-	Use:   "validate",
-	Short: "Validate candle continuity (gaps / ordering) in the database",
+	Use:         "validate",
+	Short:       "Audit candle data quality (gaps, alignment, close_time, non-final bars, zero volume, flat bars, price jumps)",
+	Annotations: reportOutput,
 	RunE: func(command *cobra.Command, args []string) error {
 		ctx := context.Background()
 
-		database, err := store.OpenSQLite(databasePath)
+		failOn, err := ingest.ParseSeverity(validateFailOn)
 		if err != nil {
 			return err
 		}
-		defer database.Close()
 
-		intervalMillis, err := candles.TimeframeToMillis(validateTimeframe)
+		database, err := store.OpenSQLite(databasePath)
 		if err != nil {
 			return err
 		}
+		defer database.Close()
 
-		validationResult, err := ingest.ValidateCandleContinuity(
-			ctx,
-			database,
-			"binance",
-			validateSymbol,
-			validateTimeframe,
-			intervalMillis,
-		)
+		report, err := runValidate(ctx, database, validateOptions{
+			DB:         databasePath,
+			Exchange:   exchangeName,
+			Symbol:     validateSymbol,
+			Timeframe:  validateTimeframe,
+			JumpSigmas: validateJumpSigmas,
+			JumpWindow: validateJumpWindow,
+			MaxIssues:  validateMaxIssues,
+		})
 		if err != nil {
 			return err
 		}
-
-		fmt.Println("db:", databasePath)
-		fmt.Println("exchange: binance")
-		fmt.Println("symbol:", validateSymbol)
-		fmt.Println("timeframe:", validateTimeframe)
-		fmt.Println("count:", validationResult.Count)
-		fmt.Println("first ts:", validationResult.FirstTS)
-		fmt.Println("last ts:", validationResult.LastTS)
-		fmt.Println("gaps:", len(validationResult.Gaps))
-
-		maxGapsToPrint := 5
-		for i, gap := range validationResult.Gaps {
-			if i >= maxGapsToPrint {
-				break
-			}
-			fmt.Printf(
-				"gap %d: prev=%d expected=%d actual=%d missingIntervals=%d\n",
-				i+1, gap.PreviousTS, gap.ExpectedTS, gap.ActualTS, gap.Missing,
-			)
+		if err := writeReport(report); err != nil {
+			return err
 		}
 
+		if err := report.failure(failOn); err != nil {
+			// A failed audit is a result, not a usage mistake
+			command.SilenceUsage = true
+			return err
+		}
 		return nil
 	},
 }
 
+// validateOptions are the validate flags; quant run fills them from the pipeline config.
+type validateOptions struct {
+	DB         string // database path, shown in the report
+	Exchange   string
+	Symbol     string
+	Timeframe  string
+	JumpSigmas float64
+	JumpWindow int
+	MaxIssues  int
+}
+
+func runValidate(ctx context.Context, database *sql.DB, options validateOptions) (validateReport, error) {
+	timeframe, err := candles.ParseTimeframe(options.Timeframe)
+	if err != nil {
+		return validateReport{}, err
+	}
+	options.Timeframe = timeframe.String()
+
+	report, err := ingest.AuditCandles(
+		ctx,
+		database,
+		options.Exchange,
+		options.Symbol,
+		timeframe,
+		ingest.AuditConfig{JumpSigmas: options.JumpSigmas, JumpWindow: options.JumpWindow},
+	)
+	if err != nil {
+		return validateReport{}, err
+	}
+	return validateReport{
+		DB:          options.DB,
+		Exchange:    options.Exchange,
+		Symbol:      options.Symbol,
+		Timeframe:   options.Timeframe,
+		AuditReport: report,
+		CheckCounts: report.CountByCheck(),
+		MaxIssues:   options.MaxIssues,
+	}, nil
+}
+
+type validateReport struct {
+	DB        string `json:"db"`
+	Exchange  string `json:"exchange"`
+	Symbol    string `json:"symbol"`
+	Timeframe string `json:"timeframe"`
+
+	// Validation (every gap) and every issue, regardless of --max-issues
+	ingest.AuditReport
+	CheckCounts map[string]int `json:"check_counts"`
+
+	MaxIssues int `json:"-"`
+}
+
+// failure is the --fail-on error, or nil when no issue reaches failOn (0 never fails).
+func (report validateReport) failure(failOn ingest.Severity) error {
+	if failOn > 0 {
+		if failing := report.CountAtLeast(failOn); failing > 0 {
+			return fmt.Errorf("validation failed: %d issue(s) at or above %s", failing, failOn)
+		}
+	}
+	return nil
+}
+
+func (report validateReport) writeText(writer io.Writer) {
+	validationResult := report.Validation
+
+	fmt.Fprintln(writer, "db:", report.DB)
+	fmt.Fprintln(writer, "exchange:", report.Exchange)
+	fmt.Fprintln(writer, "symbol:", report.Symbol)
+	fmt.Fprintln(writer, "timeframe:", report.Timeframe)
+	fmt.Fprintln(writer, "count:", validationResult.Count)
+	fmt.Fprintln(writer, "first ts:", validationResult.FirstTS)
+	fmt.Fprintln(writer, "last ts:", validationResult.LastTS)
+	fmt.Fprintln(writer, "gaps:", len(validationResult.Gaps))
+	fmt.Fprintln(writer, "known gaps (ignored):", len(validationResult.KnownGaps))
+
+	for _, check := range ingest.AuditChecks {
+		fmt.Fprintf(writer, "check %s: %d\n", check, report.CheckCounts[check])
+	}
+	fmt.Fprintln(writer, "issues:", len(report.Issues))
+	for _, severity := range []ingest.Severity{ingest.SeverityError, ingest.SeverityWarning, ingest.SeverityInfo} {
+		fmt.Fprintf(writer, "  %s: %d\n", severity, report.CountAtLeast(severity)-report.CountAtLeast(severity+1))
+	}
+
+	for i, issue := range report.Issues {
+		if i >= report.MaxIssues {
+			fmt.Fprintf(writer, "... %d more\n", len(report.Issues)-i)
+			break
+		}
+		fmt.Fprintf(writer, "%s %s ts=%d: %s\n", issue.Severity, issue.Check, issue.Timestamp, issue.Detail)
+	}
+}
+
+// csvTable lists every issue; gaps appear as issues with check=gap.
+func (report validateReport) csvTable() ([]string, [][]string) {
+	header := []string{"severity", "check", "timestamp", "detail"}
+	records := make([][]string, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		records = append(records, []string{issue.Severity.String(), issue.Check, csvInt(issue.Timestamp), issue.Detail})
+	}
+	return header, records
+}
+
 func init() {
 	validateCommand.Flags().StringVar(&validateSymbol, "symbol", "BTCUSDT", "Symbol (e.g. BTCUSDT)")
 	validateCommand.Flags().StringVar(&validateTimeframe, "timeframe", "4h", "Timeframe (e.g. 4h)")
+	validateCommand.Flags().StringVar(&validateFailOn, "fail-on", "none", "Exit non-zero when an issue at or above this severity exists: none, info, warning, error")
+	validateCommand.Flags().Float64Var(&validateJumpSigmas, "jump-sigmas", ingest.DefaultAuditConfig().JumpSigmas, "Flag close-to-close moves beyond this many rolling standard deviations")
+	validateCommand.Flags().IntVar(&validateJumpWindow, "jump-window", ingest.DefaultAuditConfig().JumpWindow, "Number of previous returns in the rolling standard deviation")
+	validateCommand.Flags().IntVar(&validateMaxIssues, "max-issues", 20, "Print at most this many issues")
 }
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/exchange"
+	"btc-4h-prediction-model/internal/ingest"
+)
+
+// Pipeline is a quant run config: every exchange × symbol × timeframe combination goes through
+// ingest → validate → features → labels → train → paper with the same settings.
+// Omitted settings keep the defaults of the matching CLI flags; settings where zero is a valid
+// choice are pointers so an explicit 0 is kept.
+type Pipeline struct {
+	DB         string   `yaml:"db"` // empty = --db
+	Exchanges  []string `yaml:"exchanges"`
+	Symbols    []string `yaml:"symbols"`
+	Timeframes []string `yaml:"timeframes"`
+
+	Ingest   IngestSettings   `yaml:"ingest"`
+	Validate ValidateSettings `yaml:"validate"`
+	Features FeatureSettings  `yaml:"features"`
+	Labels   LabelSettings    `yaml:"labels"`
+	Train    TrainSettings    `yaml:"train"`
+	Paper    PaperSettings    `yaml:"paper"`
+}
+
+type IngestSettings struct {
+	Days         *int   `yaml:"days"`
+	Incremental  bool   `yaml:"incremental"`
+	BackfillGaps bool   `yaml:"backfill_gaps"`
+	WeightBudget int    `yaml:"weight_budget"`
+	BaseURL      string `yaml:"base_url"`
+}
+
+type ValidateSettings struct {
+	FailOn     string   `yaml:"fail_on"`     // none, info, warning, error
+	JumpSigmas *float64 `yaml:"jump_sigmas"` // 0 disables the price-jump check
+	JumpWindow *int     `yaml:"jump_window"`
+}
+
+type FeatureSettings struct {
+	FuturesExchange    string `yaml:"futures_exchange"`
+	FuturesSymbol      string `yaml:"futures_symbol"` // empty = the combination's symbol
+	OpenInterestPeriod string `yaml:"oi_period"`      // empty = the combination's timeframe
+
+	// Registry feature specs, e.g. ["ema(20)", "vol(50)"]; computed by the features stage
+	// and added as model inputs by the train stage
+	Registry []string `yaml:"registry"`
+
+	// Rebuild every row instead of resuming from the saved feature state
+	Full bool `yaml:"full"`
+}
+
+type LabelSettings struct {
+	ThresholdB *float64 `yaml:"b"`
+}
+
+type TrainSettings struct {
+	Folds            int      `yaml:"folds"`
+	Epochs           int      `yaml:"epochs"`
+	LearningRate     float64  `yaml:"lr"`
+	L2Lambda         *float64 `yaml:"l2"`
+	Seed             *int64   `yaml:"seed"`
+	WritePredictions *bool    `yaml:"write_predictions"`
+}
+
+type PaperSettings struct {
+	Model      string    `yaml:"model"`
+	Thresholds []float64 `yaml:"thresholds"`
+	Fee        *float64  `yaml:"fee"`
+	Slippage   float64   `yaml:"slippage"`
+	OutDir     *string   `yaml:"out"` // "" disables equity CSVs
+}
+
+// Combination is one series the pipeline runs end to end.
+type Combination struct {
+	Exchange  string
+	Symbol    string
+	Timeframe string
+}
+
+func (combination Combination) String() string {
+	return combination.Exchange + " " + combination.Symbol + " " + combination.Timeframe
+}
+
+// LoadPipeline reads a YAML pipeline config, fills defaults and rejects unknown keys.
+func LoadPipeline(path string) (Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Pipeline{}, err
+	}
+
+	var pipeline Pipeline
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&pipeline); err != nil {
+		return Pipeline{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	pipeline.applyDefaults()
+	if err := pipeline.validate(); err != nil {
+		return Pipeline{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return pipeline, nil
+}
+
+func (pipeline *Pipeline) applyDefaults() {
+	if pipeline.Ingest.Days == nil {
+		days := 30
+		pipeline.Ingest.Days = &days
+	}
+	if pipeline.Validate.FailOn == "" {
+		pipeline.Validate.FailOn = "error"
+	}
+	if pipeline.Validate.JumpSigmas == nil {
+		jumpSigmas := ingest.DefaultAuditConfig().JumpSigmas
+		pipeline.Validate.JumpSigmas = &jumpSigmas
+	}
+	if pipeline.Validate.JumpWindow == nil {
+		jumpWindow := ingest.DefaultAuditConfig().JumpWindow
+		pipeline.Validate.JumpWindow = &jumpWindow
+	}
+	if pipeline.Features.FuturesExchange == "" {
+		pipeline.Features.FuturesExchange = "binance_usdm"
+	}
+	if pipeline.Labels.ThresholdB == nil {
+		thresholdB := 0.006
+		pipeline.Labels.ThresholdB = &thresholdB
+	}
+	if pipeline.Train.Folds == 0 {
+		pipeline.Train.Folds = 5
+	}
+	if pipeline.Train.Epochs == 0 {
+		pipeline.Train.Epochs = 500
+	}
+	if pipeline.Train.LearningRate == 0 {
+		pipeline.Train.LearningRate = 0.5
+	}
+	if pipeline.Train.L2Lambda == nil {
+		l2Lambda := 0.001
+		pipeline.Train.L2Lambda = &l2Lambda
+	}
+	if pipeline.Train.Seed == nil {
+		seed := int64(42)
+		pipeline.Train.Seed = &seed
+	}
+	if pipeline.Train.WritePredictions == nil {
+		writePredictions := true
+		pipeline.Train.WritePredictions = &writePredictions
+	}
+	if pipeline.Paper.Model == "" {
+		pipeline.Paper.Model = "logreg_softmax"
+	}
+	if len(pipeline.Paper.Thresholds) == 0 {
+		pipeline.Paper.Thresholds = []float64{0.40, 0.45, 0.50}
+	}
+	if pipeline.Paper.Fee == nil {
+		fee := 0.0004
+		pipeline.Paper.Fee = &fee
+	}
+	if pipeline.Paper.OutDir == nil {
+		outDir := "reports"
+		pipeline.Paper.OutDir = &outDir
+	}
+}
+
+func (pipeline Pipeline) validate() error {
+	if len(pipeline.Exchanges) == 0 {
+		return fmt.Errorf("exchanges: at least one exchange is required")
+	}
+	if len(pipeline.Symbols) == 0 {
+		return fmt.Errorf("symbols: at least one symbol is required")
+	}
+	if len(pipeline.Timeframes) == 0 {
+		return fmt.Errorf("timeframes: at least one timeframe is required")
+	}
+	for _, timeframe := range pipeline.Timeframes {
+		if _, err := candles.ParseTimeframe(timeframe); err != nil {
+			return fmt.Errorf("timeframes: %w", err)
+		}
+	}
+	// Fail on a typo now rather than deep inside the ingest stage
+	for _, exchangeName := range pipeline.Exchanges {
+		source, err := exchange.NewCandleSource(exchangeName, exchange.SourceOptions{})
+		if err != nil {
+			return fmt.Errorf("exchanges: %w", err)
+		}
+		for _, timeframe := range pipeline.Timeframes {
+			if !exchange.SupportsTimeframe(source, timeframe) {
+				return fmt.Errorf("timeframes: %s does not support %q (supported: %s)", exchangeName, timeframe, strings.Join(source.Timeframes(), ", "))
+			}
+		}
+	}
+	if *pipeline.Ingest.Days <= 0 {
+		return fmt.Errorf("ingest.days must be > 0")
+	}
+	if *pipeline.Validate.JumpSigmas < 0 || *pipeline.Validate.JumpWindow < 0 {
+		return fmt.Errorf("validate.jump_sigmas and validate.jump_window must be >= 0")
+	}
+	if *pipeline.Labels.ThresholdB < 0 {
+		return fmt.Errorf("labels.b must be >= 0")
+	}
+	if pipeline.Train.Folds < 1 || pipeline.Train.Epochs < 1 {
+		return fmt.Errorf("train.folds and train.epochs must be > 0")
+	}
+	if *pipeline.Train.L2Lambda < 0 {
+		return fmt.Errorf("train.l2 must be >= 0")
+	}
+	if *pipeline.Paper.Fee < 0 || pipeline.Paper.Slippage < 0 {
+		return fmt.Errorf("paper.fee and paper.slippage must be >= 0")
+	}
+	return nil
+}
+
+// Combinations lists exchange × symbol × timeframe in config order, exchange outermost.
+func (pipeline Pipeline) Combinations() []Combination {
+	var combinations []Combination
+	for _, exchangeName := range pipeline.Exchanges {
+		for _, symbol := range pipeline.Symbols {
+			for _, timeframe := range pipeline.Timeframes {
+				combinations = append(combinations, Combination{Exchange: exchangeName, Symbol: symbol, Timeframe: timeframe})
+			}
+		}
+	}
+	return combinations
+}
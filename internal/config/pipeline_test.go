@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadPipelineText(t *testing.T, text string) (Pipeline, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pipeline.yaml")
+	if err := os.WriteFile(path, []byte("exchanges: [binance]\nsymbols: [BTCUSDT]\ntimeframes: [4h]\n"+text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadPipeline(path)
+}
+
+func TestLoadPipelineDefaultsOmittedSettings(t *testing.T) {
+	pipeline, err := loadPipelineText(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *pipeline.Ingest.Days != 30 || *pipeline.Validate.JumpSigmas != 8 || *pipeline.Validate.JumpWindow != 50 ||
+		*pipeline.Labels.ThresholdB != 0.006 || *pipeline.Train.L2Lambda != 0.001 || *pipeline.Train.Seed != 42 {
+		t.Fatalf("defaults = days %d jump %v/%d b %v l2 %v seed %d", *pipeline.Ingest.Days, *pipeline.Validate.JumpSigmas,
+			*pipeline.Validate.JumpWindow, *pipeline.Labels.ThresholdB, *pipeline.Train.L2Lambda, *pipeline.Train.Seed)
+	}
+}
+
+func TestLoadPipelineKeepsExplicitZeros(t *testing.T) {
+	pipeline, err := loadPipelineText(t, `
+validate: {jump_sigmas: 0, jump_window: 0}
+labels: {b: 0}
+train: {l2: 0, seed: 0}
+paper: {fee: 0, out: ""}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *pipeline.Validate.JumpSigmas != 0 || *pipeline.Validate.JumpWindow != 0 || *pipeline.Labels.ThresholdB != 0 ||
+		*pipeline.Train.L2Lambda != 0 || *pipeline.Train.Seed != 0 || *pipeline.Paper.Fee != 0 || *pipeline.Paper.OutDir != "" {
+		t.Fatalf("explicit zeros were replaced: %+v", pipeline)
+	}
+}
+
+func TestLoadPipelineRejectsNonPositiveDays(t *testing.T) {
+	for _, days := range []string{"0", "-1"} {
+		_, err := loadPipelineText(t, "ingest: {days: "+days+"}\n")
+		if err == nil || !strings.Contains(err.Error(), "ingest.days must be > 0") {
+			t.Errorf("days %s: err = %v, want ingest.days must be > 0", days, err)
+		}
+	}
+}
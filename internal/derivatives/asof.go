@@ -0,0 +1,20 @@
+package derivatives
+
+// AsOfIndexes returns, for each query time, the index of the last sample at or before it, or -1
+// when none is. A sample more than maxAgeMillis older than the query time counts as missing
+// (0 = no limit), so a series that stopped updating is not carried forward indefinitely.
+// Both slices must be ascending.
+func AsOfIndexes(queryTimes []int64, sampleTimes []int64, maxAgeMillis int64) []int {
+	result := make([]int, len(queryTimes))
+	sample := -1
+	for i, queryTime := range queryTimes {
+		for sample+1 < len(sampleTimes) && sampleTimes[sample+1] <= queryTime {
+			sample++
+		}
+		result[i] = sample
+		if sample >= 0 && maxAgeMillis > 0 && queryTime-sampleTimes[sample] > maxAgeMillis {
+			result[i] = -1
+		}
+	}
+	return result
+}
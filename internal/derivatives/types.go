@@ -0,0 +1,22 @@
+package derivatives
+
+// FundingRate is one settled funding payment of a perpetual contract.
+type FundingRate struct {
+	Exchange string
+	Symbol   string
+
+	FundingTime int64 // settlement time in ms (UTC)
+	Rate        float64
+	MarkPrice   *float64 // not reported for older settlements
+}
+
+// OpenInterest is one open-interest history sample, taken at Timestamp.
+type OpenInterest struct {
+	Exchange string
+	Symbol   string
+	Period   string
+
+	Timestamp            int64
+	SumOpenInterest      float64 // contracts (base asset for USD-M)
+	SumOpenInterestValue float64 // quote value
+}
@@ -0,0 +1,175 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+type BackfillConfig struct {
+	// ChunkMillis is the width of each time range fetched by one worker (cut at bar boundaries).
+	ChunkMillis int64
+	Workers     int
+}
+
+type backfillChunk struct {
+	startTimeMillis int64
+	endTimeMillis   int64
+}
+
+type backfillResult struct {
+	index   int // position of the chunk in time order
+	candles []candles.Candle
+	err     error
+}
+
+// BackfillCandles splits [startTimeMillis, endTimeMillis] into chunks and fetches them with a
+// bounded worker pool. The workers share source, so a Binance client's weight limiter paces all
+// of them together. Chunks are fetched out of order but deduped and handed to sink in time
+// order, from one goroutine, so sink can write to the database directly. The first failure stops
+// the backfill with nothing after the failed chunk written: what is stored stays a contiguous
+// prefix of the range, and an incremental rerun resumes from the failed chunk. Returns the
+// number of candles passed to sink.
+func BackfillCandles(
+	ctx context.Context,
+	source CandleSource,
+	symbol string,
+	timeframe string,
+	startTimeMillis int64,
+	endTimeMillis int64,
+	config BackfillConfig,
+	sink func([]candles.Candle) error,
+) (int, error) {
+	parsedTimeframe, err := candles.ParseTimeframe(timeframe)
+	if err != nil {
+		return 0, err
+	}
+	if config.Workers <= 0 {
+		return 0, fmt.Errorf("backfill workers must be > 0")
+	}
+	if config.ChunkMillis < parsedTimeframe.NominalMillis() {
+		return 0, fmt.Errorf("backfill chunk must cover at least one %s interval", timeframe)
+	}
+	if endTimeMillis < startTimeMillis {
+		return 0, nil
+	}
+
+	chunks := splitBackfillRange(startTimeMillis, endTimeMillis, config.ChunkMillis, parsedTimeframe)
+
+	workerContext, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunkQueue := make(chan int)
+	results := make(chan backfillResult, config.Workers)
+
+	var workers sync.WaitGroup
+	for i := 0; i < config.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for index := range chunkQueue {
+				chunk := chunks[index]
+				chunkCandles, err := source.FetchCandles(workerContext, symbol, timeframe, chunk.startTimeMillis, chunk.endTimeMillis)
+				if err != nil {
+					err = fmt.Errorf("chunk %d..%d: %w", chunk.startTimeMillis, chunk.endTimeMillis, err)
+				}
+				select {
+				case results <- backfillResult{index: index, candles: dedupeChunk(chunkCandles, chunk), err: err}:
+				case <-workerContext.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(chunkQueue)
+		for index := range chunks {
+			select {
+			case chunkQueue <- index:
+			case <-workerContext.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	written := 0
+	var firstErr error
+	// Chunks that arrived ahead of an earlier one wait here until it has been written
+	pending := map[int]backfillResult{}
+	next := 0
+	for result := range results {
+		if firstErr != nil {
+			continue // drain so workers can exit
+		}
+		pending[result.index] = result
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if ready.err == nil {
+				ready.err = sink(ready.candles)
+			}
+			if ready.err != nil {
+				firstErr = ready.err
+				cancel()
+				break
+			}
+			written += len(ready.candles)
+			next++
+		}
+	}
+
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = ctx.Err()
+	}
+	return written, firstErr
+}
+
+func splitBackfillRange(startTimeMillis int64, endTimeMillis int64, chunkMillis int64, timeframe candles.Timeframe) []backfillChunk {
+	var chunks []backfillChunk
+	for chunkStart := startTimeMillis; chunkStart <= endTimeMillis; {
+		// Cut on a bar boundary so no bar straddles two chunks; always advance at least one bar
+		nextStart := timeframe.Floor(chunkStart + chunkMillis)
+		if nextStart <= chunkStart {
+			nextStart = timeframe.Next(chunkStart)
+		}
+		chunkEnd := nextStart - 1
+		if chunkEnd > endTimeMillis {
+			chunkEnd = endTimeMillis
+		}
+		chunks = append(chunks, backfillChunk{startTimeMillis: chunkStart, endTimeMillis: chunkEnd})
+		chunkStart = nextStart
+	}
+	return chunks
+}
+
+// dedupeChunk sorts a chunk, keeps one candle per open time and drops anything a source
+// returned outside the requested range.
+func dedupeChunk(chunkCandles []candles.Candle, chunk backfillChunk) []candles.Candle {
+	sort.SliceStable(chunkCandles, func(i, j int) bool { return chunkCandles[i].Timestamp < chunkCandles[j].Timestamp })
+
+	result := chunkCandles[:0]
+	for _, candle := range chunkCandles {
+		if candle.Timestamp < chunk.startTimeMillis || candle.Timestamp > chunk.endTimeMillis {
+			continue
+		}
+		if len(result) > 0 && result[len(result)-1].Timestamp == candle.Timestamp {
+			// Keep the later copy; it is the more recent view of that bar
+			result[len(result)-1] = candle
+			continue
+		}
+		result = append(result, candle)
+	}
+	return result
+}
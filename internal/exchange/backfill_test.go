@@ -0,0 +1,185 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+func utcMillis(year int, month time.Month, day int, hour int) int64 {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.UTC).UnixMilli()
+}
+
+func TestSplitBackfillRangeCutsAtBarBoundaries(t *testing.T) {
+	const day = 24 * 60 * 60 * 1000
+	tests := []struct {
+		name        string
+		timeframe   string
+		start, end  int64
+		chunkMillis int64
+		want        []backfillChunk
+	}{
+		{
+			// 45 days always reach into the next month, so every chunk is one calendar month
+			name: "months", timeframe: "1M", chunkMillis: 45 * day,
+			start: utcMillis(2023, time.December, 1, 0), end: utcMillis(2024, time.March, 15, 0),
+			want: []backfillChunk{
+				{utcMillis(2023, time.December, 1, 0), utcMillis(2024, time.January, 1, 0) - 1},
+				{utcMillis(2024, time.January, 1, 0), utcMillis(2024, time.February, 1, 0) - 1},
+				{utcMillis(2024, time.February, 1, 0), utcMillis(2024, time.March, 1, 0) - 1},
+				{utcMillis(2024, time.March, 1, 0), utcMillis(2024, time.March, 15, 0)},
+			},
+		},
+		{
+			// Weeks start on Monday; 2024-02-26 is one
+			name: "weeks", timeframe: "1w", chunkMillis: 10 * day,
+			start: utcMillis(2024, time.February, 26, 0), end: utcMillis(2024, time.March, 18, 0) - 1,
+			want: []backfillChunk{
+				{utcMillis(2024, time.February, 26, 0), utcMillis(2024, time.March, 4, 0) - 1},
+				{utcMillis(2024, time.March, 4, 0), utcMillis(2024, time.March, 11, 0) - 1},
+				{utcMillis(2024, time.March, 11, 0), utcMillis(2024, time.March, 18, 0) - 1},
+			},
+		},
+		{
+			// An unaligned start (a lookback from now) keeps its first partial bar in the first chunk
+			name: "unaligned start", timeframe: "4h", chunkMillis: day,
+			start: utcMillis(2024, time.March, 1, 1) + 30*60*1000, end: utcMillis(2024, time.March, 2, 10),
+			want: []backfillChunk{
+				{utcMillis(2024, time.March, 1, 1) + 30*60*1000, utcMillis(2024, time.March, 2, 0) - 1},
+				{utcMillis(2024, time.March, 2, 0), utcMillis(2024, time.March, 2, 10)},
+			},
+		},
+		{
+			// A chunk narrower than the bar still advances a whole bar
+			name: "chunk below one bar", timeframe: "1d", chunkMillis: day / 2,
+			start: utcMillis(2024, time.March, 1, 0), end: utcMillis(2024, time.March, 3, 0) - 1,
+			want: []backfillChunk{
+				{utcMillis(2024, time.March, 1, 0), utcMillis(2024, time.March, 2, 0) - 1},
+				{utcMillis(2024, time.March, 2, 0), utcMillis(2024, time.March, 3, 0) - 1},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			timeframe, err := candles.ParseTimeframe(test.timeframe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := splitBackfillRange(test.start, test.end, test.chunkMillis, timeframe)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("chunks:\n got  %v\n want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDedupeChunk(t *testing.T) {
+	candle := func(timestamp int64, close float64) candles.Candle {
+		return candles.Candle{Timestamp: timestamp, Close: close}
+	}
+	chunk := backfillChunk{startTimeMillis: 100, endTimeMillis: 300}
+	// Out of order, with an overlap repeat of 200 and bars outside the chunk on both sides
+	got := dedupeChunk([]candles.Candle{candle(300, 3), candle(200, 2), candle(50, 0), candle(100, 1), candle(200, 2.5), candle(400, 4)}, chunk)
+	want := []candles.Candle{candle(100, 1), candle(200, 2.5), candle(300, 3)}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("deduped:\n got  %+v\n want %+v", got, want)
+	}
+}
+
+// chunkSource returns one 1d candle per day of the requested range; fetch decides per chunk
+// (by its start) how long to take and whether to fail.
+type chunkSource struct {
+	fetch func(ctx context.Context, startTimeMillis int64) error
+}
+
+func (source chunkSource) Name() string           { return "test" }
+func (source chunkSource) Timeframes() []string   { return []string{"1d"} }
+func (source chunkSource) ReportsOrderFlow() bool { return false }
+
+func (source chunkSource) FetchCandles(ctx context.Context, symbol string, timeframe string, startTimeMillis int64, endTimeMillis int64) ([]candles.Candle, error) {
+	if err := source.fetch(ctx, startTimeMillis); err != nil {
+		return nil, err
+	}
+	var result []candles.Candle
+	for open := startTimeMillis; open <= endTimeMillis; open += 24 * 60 * 60 * 1000 {
+		result = append(result, candles.Candle{Timeframe: timeframe, Timestamp: open, IsFinal: true})
+	}
+	return result, nil
+}
+
+func TestBackfillCandlesWritesInOrderAndStopsAtTheFailedChunk(t *testing.T) {
+	const day = 24 * 60 * 60 * 1000
+	start := utcMillis(2024, time.March, 1, 0)
+	failing := start + 2*day
+	fetchFailed := errors.New("503 Service Unavailable")
+
+	source := chunkSource{fetch: func(ctx context.Context, chunkStart int64) error {
+		switch {
+		case chunkStart == start:
+			// The first chunk is slow, so later chunks (and the failure) arrive before it
+			time.Sleep(50 * time.Millisecond)
+		case chunkStart == failing:
+			time.Sleep(10 * time.Millisecond)
+			return fetchFailed
+		}
+		return nil
+	}}
+
+	var mutex sync.Mutex
+	var written []int64
+	count, err := BackfillCandles(context.Background(), source, "BTCUSDT", "1d", start, start+6*day-1,
+		BackfillConfig{ChunkMillis: day, Workers: 6},
+		func(chunkCandles []candles.Candle) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, candle := range chunkCandles {
+				written = append(written, candle.Timestamp)
+			}
+			return nil
+		})
+
+	if !errors.Is(err, fetchFailed) {
+		t.Fatalf("err = %v, want the failed chunk's error", err)
+	}
+	// Only the days before the failed chunk, in time order; the later chunks were fetched but not written
+	if want := []int64{start, start + day}; fmt.Sprint(written) != fmt.Sprint(want) || count != len(want) {
+		t.Fatalf("wrote %v (count %d), want %v", written, count, want)
+	}
+}
+
+func TestBackfillCandlesWritesEveryChunkInOrder(t *testing.T) {
+	const day = 24 * 60 * 60 * 1000
+	start := utcMillis(2024, time.March, 1, 0)
+	source := chunkSource{fetch: func(ctx context.Context, chunkStart int64) error {
+		// Earlier chunks take longer
+		time.Sleep(time.Duration(start+10*day-chunkStart) / day * time.Millisecond)
+		return nil
+	}}
+
+	var written []int64
+	count, err := BackfillCandles(context.Background(), source, "BTCUSDT", "1d", start, start+10*day-1,
+		BackfillConfig{ChunkMillis: 2 * day, Workers: 4},
+		func(chunkCandles []candles.Candle) error {
+			for _, candle := range chunkCandles {
+				written = append(written, candle.Timestamp)
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 10 || len(written) != 10 {
+		t.Fatalf("wrote %d candles (count %d), want 10", len(written), count)
+	}
+	for i, timestamp := range written {
+		if timestamp != start+int64(i)*day {
+			t.Fatalf("write %d at %d, want %d: chunks reached sink out of order", i, timestamp, start+int64(i)*day)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package exchange
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+// data.binance.vision switched spot kline timestamps from milliseconds to microseconds on
+// 2025-01-01. Any epoch value above this is in microseconds (ms values stay below it until ~5138).
+const binanceArchiveMicrosecondThreshold = 100_000_000_000_000
+
+var ErrBinanceArchiveChecksumMissing = errors.New("checksum file missing")
+
+// ParseBinanceArchiveName extracts symbol and interval from archive names such as
+// BTCUSDT-4h-2024-01.zip (monthly) or BTCUSDT-4h-2024-01-15.zip (daily).
+func ParseBinanceArchiveName(fileName string) (symbol string, timeframe string, err error) {
+	base := strings.TrimSuffix(filepath.Base(fileName), ".zip")
+	parts := strings.Split(base, "-")
+	if len(parts) < 4 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unexpected archive name %q (want SYMBOL-INTERVAL-YYYY-MM[-DD].zip)", fileName)
+	}
+	return parts[0], parts[1], nil
+}
+
+// VerifyBinanceArchiveChecksum compares the archive's SHA-256 with its companion
+// <archive>.CHECKSUM file ("<hex>  <file name>").
+func VerifyBinanceArchiveChecksum(zipPath string) error {
+	checksumBody, err := os.ReadFile(zipPath + ".CHECKSUM")
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s: %w", filepath.Base(zipPath), ErrBinanceArchiveChecksumMissing)
+		}
+		return err
+	}
+	fields := strings.Fields(string(checksumBody))
+	if len(fields) == 0 {
+		return fmt.Errorf("%s.CHECKSUM is empty", filepath.Base(zipPath))
+	}
+	expected := strings.ToLower(fields[0])
+
+	file, err := os.Open(zipPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(hasher.Sum(nil))
+
+	if actual != expected {
+		return fmt.Errorf("%s: checksum mismatch (expected %s, got %s)", filepath.Base(zipPath), expected, actual)
+	}
+	return nil
+}
+
+// ReadBinanceArchive parses every CSV inside a data.binance.vision kline zip.
+// Archived bars are closed by definition, so all candles are final.
+func ReadBinanceArchive(zipPath string, exchangeName string, symbol string, timeframe string) ([]candles.Candle, error) {
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	var result []candles.Candle
+	for _, entry := range archive.File {
+		if !strings.HasSuffix(strings.ToLower(entry.Name), ".csv") {
+			continue
+		}
+
+		entryReader, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		entryCandles, err := ParseBinanceArchiveCSV(entryReader, exchangeName, symbol, timeframe)
+		entryReader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", filepath.Base(zipPath), entry.Name, err)
+		}
+
+		result = append(result, entryCandles...)
+	}
+
+	return result, nil
+}
+
+// ParseBinanceArchiveCSV reads archive kline rows, which use the REST kline column order
+// (open_time, open, high, low, close, volume, close_time, ...). Futures archives carry a header
+// row, spot archives do not; both millisecond and microsecond timestamps are accepted.
+func ParseBinanceArchiveCSV(reader io.Reader, exchangeName string, symbol string, timeframe string) ([]candles.Candle, error) {
+	csvReader := csv.NewReader(bufio.NewReader(reader))
+	csvReader.FieldsPerRecord = -1
+
+	var result []candles.Candle
+	for line := 1; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 7 {
+			return nil, fmt.Errorf("line %d: unexpected kline length: %d", line, len(record))
+		}
+		if line == 1 && record[0] == "open_time" {
+			continue
+		}
+
+		openTime, err := parseArchiveTimestamp(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: open_time: %w", line, err)
+		}
+		closeTime, err := parseArchiveTimestamp(record[6])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: close_time: %w", line, err)
+		}
+
+		values := make([]float64, 5)
+		for i := 0; i < 5; i++ {
+			values[i], err = strconv.ParseFloat(record[i+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: column %d: %w", line, i+1, err)
+			}
+		}
+
+		candle := candles.Candle{
+			Exchange:  exchangeName,
+			Symbol:    symbol,
+			Timeframe: timeframe,
+			Timestamp: openTime,
+			Open:      values[0],
+			High:      values[1],
+			Low:       values[2],
+			Close:     values[3],
+			Volume:    values[4],
+			CloseTime: closeTime,
+			IsFinal:   true,
+		}
+		if len(record) >= 11 {
+			trades, err := strconv.ParseInt(strings.TrimSpace(record[8]), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: count: %w", line, err)
+			}
+			if err := setKlineOrderFlow(&candle, record[7], trades, record[9], record[10]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		result = append(result, candle)
+	}
+
+	return result, nil
+}
+
+// parseArchiveTimestamp returns epoch milliseconds for a ms or µs archive timestamp.
+func parseArchiveTimestamp(value string) (int64, error) {
+	timestamp, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if timestamp > binanceArchiveMicrosecondThreshold {
+		return timestamp / 1000, nil
+	}
+	return timestamp, nil
+}
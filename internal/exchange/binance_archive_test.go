@@ -0,0 +1,112 @@
+package exchange
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func archiveFixture(parts ...string) string {
+	return filepath.Join(append([]string{"testdata", "binance_archive"}, parts...)...)
+}
+
+func TestReadBinanceArchiveEras(t *testing.T) {
+	const fourHours = 4 * 60 * 60 * 1000
+	tests := []struct {
+		name       string
+		path       string
+		timestamps []int64
+		firstClose float64
+		trades     int64
+	}{
+		// Spot before 2025: millisecond timestamps, no header row
+		{"spot milliseconds", archiveFixture("spot", "BTCUSDT-4h-2024-12.zip"), []int64{1735660800000, 1735675200000}, 93850.25, 154321},
+		// Spot from 2025-01-01: microsecond timestamps, still no header row
+		{"spot microseconds", archiveFixture("spot", "BTCUSDT-4h-2025-01.zip"), []int64{1735689600000, 1735704000000}, 94100.10, 140001},
+		// Futures: millisecond timestamps after a header row
+		{"futures header", archiveFixture("futures", "BTCUSDT-4h-2024-12.zip"), []int64{1735660800000}, 93800, 410000},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := VerifyBinanceArchiveChecksum(test.path); err != nil {
+				t.Fatalf("checksum: %v", err)
+			}
+			symbol, timeframe, err := ParseBinanceArchiveName(test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadBinanceArchive(test.path, "binance", symbol, timeframe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(test.timestamps) {
+				t.Fatalf("got %d candles, want %d", len(got), len(test.timestamps))
+			}
+			for i, candle := range got {
+				if candle.Timestamp != test.timestamps[i] || candle.CloseTime != test.timestamps[i]+fourHours-1 {
+					t.Errorf("candle %d spans %d..%d, want %d..%d", i, candle.Timestamp, candle.CloseTime, test.timestamps[i], test.timestamps[i]+fourHours-1)
+				}
+				if candle.Symbol != "BTCUSDT" || candle.Timeframe != "4h" || !candle.IsFinal {
+					t.Errorf("candle %d = %+v", i, candle)
+				}
+			}
+			if got[0].Close != test.firstClose || got[0].Trades == nil || *got[0].Trades != test.trades {
+				t.Errorf("first candle close %v trades %v, want %v and %d", got[0].Close, got[0].Trades, test.firstClose, test.trades)
+			}
+		})
+	}
+}
+
+func TestParseArchiveTimestampThreshold(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"1735689600000", 1735689600000},     // 2025-01-01 in ms
+		{"1735689600000000", 1735689600000},  // the same instant in µs
+		{"1735703999999999", 1735703999999},  // a µs close time keeps its last millisecond
+		{"100000000000000", 100000000000000}, // the threshold itself is read as ms (year ~5138)
+		{"100000000000001", 100000000000},    // anything above it is µs
+		{" 1502942400000 ", 1502942400000},   // the first archived spot bar, padded
+	}
+	for _, test := range tests {
+		got, err := parseArchiveTimestamp(test.value)
+		if err != nil {
+			t.Fatalf("parseArchiveTimestamp(%q): %v", test.value, err)
+		}
+		if got != test.want {
+			t.Errorf("parseArchiveTimestamp(%q) = %d, want %d", test.value, got, test.want)
+		}
+	}
+}
+
+func TestParseBinanceArchiveCSVHeaderOnlyOnFirstLine(t *testing.T) {
+	body := "1735660800000,1,2,0.5,1.5,10,1735675199999\nopen_time,open,high,low,close,volume,close_time\n"
+	_, err := ParseBinanceArchiveCSV(strings.NewReader(body), "binance", "BTCUSDT", "4h")
+	if err == nil || !strings.Contains(err.Error(), "line 2: open_time") {
+		t.Fatalf("err = %v, want a line 2 open_time error", err)
+	}
+}
+
+func TestVerifyBinanceArchiveChecksumRejectsMismatch(t *testing.T) {
+	err := VerifyBinanceArchiveChecksum(archiveFixture("mismatch", "BTCUSDT-4h-2024-12.zip"))
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("err = %v, want a checksum mismatch", err)
+	}
+}
+
+func TestVerifyBinanceArchiveChecksumMissing(t *testing.T) {
+	body, err := os.ReadFile(archiveFixture("spot", "BTCUSDT-4h-2024-12.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "BTCUSDT-4h-2024-12.zip")
+	if err := os.WriteFile(path, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyBinanceArchiveChecksum(path); !errors.Is(err, ErrBinanceArchiveChecksumMissing) {
+		t.Fatalf("err = %v, want ErrBinanceArchiveChecksumMissing", err)
+	}
+}
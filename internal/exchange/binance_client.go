@@ -2,39 +2,134 @@ package exchange
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"sync"
+	"time"
 )
 
 type BinanceClient struct {
 	HTTPClient *http.Client
+
+	// Market selects the endpoint family; the zero value means Binance spot.
+	Market BinanceMarket
+	// BaseURL overrides Market.BaseURL (e.g. a local mock server); empty uses the market's.
+	BaseURL string
+
+	Retry RetryPolicy
+
+	// WeightBudget is the request weight this client may spend per minute.
+	WeightBudget int
+
+	limiter *weightLimiter
+	clock   *serverClock
 }
 
 func NewBinanceClient() BinanceClient {
-	return BinanceClient{HTTPClient: http.DefaultClient}
+	return NewBinanceMarketClient(BinanceSpot, 0)
+}
+
+// NewBinanceMarketClient builds a client for market. weightBudget 0 uses the market default,
+// a negative budget disables client-side weight tracking.
+func NewBinanceMarketClient(market BinanceMarket, weightBudget int) BinanceClient {
+	if weightBudget == 0 {
+		weightBudget = market.DefaultWeightBudget
+	}
+
+	client := BinanceClient{
+		HTTPClient: http.DefaultClient,
+		Market:     market,
+		Retry:      DefaultRetryPolicy(),
+		clock:      &serverClock{},
+	}
+	if weightBudget > 0 {
+		client.WeightBudget = weightBudget
+		client.limiter = newWeightLimiter(weightBudget)
+	}
+	return client
+}
+
+func (client BinanceClient) market() BinanceMarket {
+	if client.Market.Name == "" {
+		return BinanceSpot
+	}
+	return client.Market
+}
+
+func (client BinanceClient) baseURL() string {
+	if client.BaseURL != "" {
+		return client.BaseURL
+	}
+	return client.market().BaseURL
 }
 
 func (client BinanceClient) Get(context context.Context, requestURL string) ([]byte, error) {
-	request, err := http.NewRequestWithContext(context, http.MethodGet, requestURL, nil)
-	if err != nil {
-		return nil, err
+	return client.GetWeighted(context, requestURL, binanceDefaultRequestWeight)
+}
+
+// GetWeighted performs a GET that counts weight against the budget, retrying 429/418/5xx
+// responses and transport errors with jittered exponential backoff (or Retry-After when sent).
+func (client BinanceClient) GetWeighted(ctx context.Context, requestURL string, weight int) ([]byte, error) {
+	getter := retryingGetter{
+		httpClient:   client.HTTPClient,
+		policy:       client.Retry,
+		exchangeName: client.market().Name,
 	}
+	if client.limiter != nil {
+		getter.beforeAttempt = func(attemptContext context.Context) error {
+			return client.limiter.reserve(attemptContext, weight)
+		}
+		getter.afterResponse = client.limiter.observe
+		getter.onRetryAfter = client.limiter.pause
+	}
+	return getter.get(ctx, requestURL)
+}
 
-	response, err := client.HTTPClient.Do(request)
+// ServerTimeMillis asks Binance for its clock, used to decide whether the last kline has closed.
+func (client BinanceClient) ServerTimeMillis(context context.Context) (int64, error) {
+	body, err := client.Get(context, BuildServerTimeURL(client.baseURL(), client.market().TimePath))
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer response.Body.Close()
 
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
+	var payload struct {
+		ServerTime int64 `json:"serverTime"`
 	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return 0, err
+	}
+	if payload.ServerTime <= 0 {
+		return 0, fmt.Errorf("binance server time missing in response: %s", string(body))
+	}
+	return payload.ServerTime, nil
+}
 
-	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("binance non-200: %s body=%s", response.Status, string(body))
+// serverClock caches the offset between the exchange clock and the local one. It is shared (by
+// pointer) between copies of a BinanceClient, so parallel fetches ask for the time only once.
+type serverClock struct {
+	mutex        sync.Mutex
+	known        bool
+	offsetMillis int64
+}
+
+// serverNowMillis is the current exchange time, from the cached clock offset. A client built
+// without NewBinanceMarketClient asks the exchange every time.
+func (client BinanceClient) serverNowMillis(ctx context.Context) (int64, error) {
+	if client.clock == nil {
+		return client.ServerTimeMillis(ctx)
 	}
 
-	return body, nil
+	client.clock.mutex.Lock()
+	defer client.clock.mutex.Unlock()
+
+	if !client.clock.known {
+		serverTime, err := client.ServerTimeMillis(ctx)
+		if err != nil {
+			return 0, err
+		}
+		client.clock.offsetMillis = serverTime - time.Now().UnixMilli()
+		client.clock.known = true
+	}
+	return time.Now().UnixMilli() + client.clock.offsetMillis, nil
 }
@@ -0,0 +1,188 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/derivatives"
+)
+
+const (
+	binanceFundingRateLimit  = 1000
+	binanceOpenInterestLimit = 500
+)
+
+type binanceFundingRateRow struct {
+	Symbol      string `json:"symbol"`
+	FundingTime int64  `json:"fundingTime"`
+	FundingRate string `json:"fundingRate"`
+	MarkPrice   string `json:"markPrice"`
+}
+
+type binanceOpenInterestRow struct {
+	Symbol               string `json:"symbol"`
+	SumOpenInterest      string `json:"sumOpenInterest"`
+	SumOpenInterestValue string `json:"sumOpenInterestValue"`
+	Timestamp            int64  `json:"timestamp"`
+}
+
+func ParseFundingRates(body []byte, exchangeName string, symbol string) ([]derivatives.FundingRate, error) {
+	var rows []binanceFundingRateRow
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, err
+	}
+
+	result := make([]derivatives.FundingRate, 0, len(rows))
+	for _, row := range rows {
+		rate, err := strconv.ParseFloat(row.FundingRate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("funding rate at %d: %w", row.FundingTime, err)
+		}
+		fundingRate := derivatives.FundingRate{
+			Exchange:    exchangeName,
+			Symbol:      symbol,
+			FundingTime: row.FundingTime,
+			Rate:        rate,
+		}
+		// Settlements before Binance started reporting it have an empty mark price
+		if row.MarkPrice != "" {
+			markPrice, err := strconv.ParseFloat(row.MarkPrice, 64)
+			if err != nil {
+				return nil, fmt.Errorf("mark price at %d: %w", row.FundingTime, err)
+			}
+			fundingRate.MarkPrice = &markPrice
+		}
+		result = append(result, fundingRate)
+	}
+	return result, nil
+}
+
+func ParseOpenInterestHist(body []byte, exchangeName string, symbol string, period string) ([]derivatives.OpenInterest, error) {
+	var rows []binanceOpenInterestRow
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, err
+	}
+
+	result := make([]derivatives.OpenInterest, 0, len(rows))
+	for _, row := range rows {
+		sumOpenInterest, err := strconv.ParseFloat(row.SumOpenInterest, 64)
+		if err != nil {
+			return nil, fmt.Errorf("open interest at %d: %w", row.Timestamp, err)
+		}
+		sumOpenInterestValue, err := strconv.ParseFloat(row.SumOpenInterestValue, 64)
+		if err != nil {
+			return nil, fmt.Errorf("open interest value at %d: %w", row.Timestamp, err)
+		}
+		result = append(result, derivatives.OpenInterest{
+			Exchange:             exchangeName,
+			Symbol:               symbol,
+			Period:               period,
+			Timestamp:            row.Timestamp,
+			SumOpenInterest:      sumOpenInterest,
+			SumOpenInterestValue: sumOpenInterestValue,
+		})
+	}
+	return result, nil
+}
+
+// FetchFundingRatesPaginated pages forward through the funding history from startTimeMillis,
+// like FetchKlinesPaginated. endTimeMillis 0 means up to now.
+func (client BinanceClient) FetchFundingRatesPaginated(
+	context context.Context,
+	symbol string,
+	startTimeMillis int64,
+	endTimeMillis int64,
+) ([]derivatives.FundingRate, error) {
+	market := client.market()
+	if market.FundingRatePath == "" {
+		return nil, fmt.Errorf("%s has no funding rate history", market.Name)
+	}
+
+	currentStartTimeMillis := startTimeMillis
+	var allRates []derivatives.FundingRate
+
+	for {
+		requestURL := BuildFundingRateURL(client.baseURL(), market.FundingRatePath, symbol, binanceFundingRateLimit, currentStartTimeMillis, endTimeMillis)
+
+		body, err := client.Get(context, requestURL)
+		if err != nil {
+			return nil, err
+		}
+
+		pageRates, err := ParseFundingRates(body, market.Name, symbol)
+		if err != nil {
+			return nil, err
+		}
+		if len(pageRates) == 0 {
+			break
+		}
+		allRates = append(allRates, pageRates...)
+
+		nextStartTimeMillis := pageRates[len(pageRates)-1].FundingTime + 1
+		if nextStartTimeMillis <= currentStartTimeMillis {
+			break
+		}
+		currentStartTimeMillis = nextStartTimeMillis
+
+		if len(pageRates) < binanceFundingRateLimit {
+			break
+		}
+		if endTimeMillis > 0 && currentStartTimeMillis > endTimeMillis {
+			break
+		}
+	}
+
+	return allRates, nil
+}
+
+// FetchOpenInterestPaginated walks [startTimeMillis, endTimeMillis] in windows of one page of
+// samples. Binance only keeps about 30 days of open-interest history, so windows before that
+// come back empty and are skipped rather than ending the walk. endTimeMillis 0 means up to now.
+func (client BinanceClient) FetchOpenInterestPaginated(
+	context context.Context,
+	symbol string,
+	period string,
+	startTimeMillis int64,
+	endTimeMillis int64,
+) ([]derivatives.OpenInterest, error) {
+	market := client.market()
+	if market.OpenInterestPath == "" {
+		return nil, fmt.Errorf("%s has no open interest history", market.Name)
+	}
+
+	timeframe, err := candles.ParseTimeframe(period)
+	if err != nil {
+		return nil, err
+	}
+	if endTimeMillis <= 0 {
+		endTimeMillis = time.Now().UnixMilli()
+	}
+	windowMillis := int64(binanceOpenInterestLimit) * timeframe.NominalMillis()
+
+	var allSamples []derivatives.OpenInterest
+	for windowStart := startTimeMillis; windowStart <= endTimeMillis; windowStart += windowMillis {
+		windowEnd := windowStart + windowMillis - 1
+		if windowEnd > endTimeMillis {
+			windowEnd = endTimeMillis
+		}
+
+		requestURL := BuildOpenInterestURL(client.baseURL(), market.OpenInterestPath, symbol, period, binanceOpenInterestLimit, windowStart, windowEnd)
+
+		body, err := client.Get(context, requestURL)
+		if err != nil {
+			return nil, err
+		}
+
+		pageSamples, err := ParseOpenInterestHist(body, market.Name, symbol, period)
+		if err != nil {
+			return nil, err
+		}
+		allSamples = append(allSamples, pageSamples...)
+	}
+
+	return allSamples, nil
+}
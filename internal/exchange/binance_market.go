@@ -0,0 +1,101 @@
+package exchange
+
+// BinanceMarket describes one Binance kline endpoint family. Name is the candles.exchange value,
+// so spot and perpetual series of the same symbol never collide in the database.
+type BinanceMarket struct {
+	Name string
+
+	BaseURL    string
+	KlinesPath string
+	TimePath   string
+
+	// StreamURL is the combined-stream WebSocket root (streams are appended as ?streams=a/b)
+	StreamURL string
+
+	KlinesLimit  int
+	KlinesWeight int
+
+	// MaxRangeMillis caps endTime-startTime per klines request (COIN-M rejects > 200 days; 0 = no cap)
+	MaxRangeMillis int64
+
+	// DefaultWeightBudget stays below the endpoint family's per-minute IP weight limit
+	DefaultWeightBudget int
+
+	// Perpetual-only history endpoints; empty when the market has none
+	FundingRatePath  string
+	OpenInterestPath string
+}
+
+var (
+	BinanceSpot = BinanceMarket{
+		Name:                "binance",
+		BaseURL:             "https://api.binance.com",
+		KlinesPath:          "/api/v3/klines",
+		TimePath:            "/api/v3/time",
+		StreamURL:           "wss://stream.binance.com:9443/stream",
+		KlinesLimit:         1000,
+		KlinesWeight:        2,
+		DefaultWeightBudget: 5000,
+	}
+	BinanceSpotTestnet = BinanceMarket{
+		Name:                "binance_testnet",
+		BaseURL:             "https://testnet.binance.vision",
+		KlinesPath:          "/api/v3/klines",
+		TimePath:            "/api/v3/time",
+		StreamURL:           "wss://stream.testnet.binance.vision/stream",
+		KlinesLimit:         1000,
+		KlinesWeight:        2,
+		DefaultWeightBudget: 5000,
+	}
+	BinanceUS = BinanceMarket{
+		Name:                "binance_us",
+		BaseURL:             "https://api.binance.us",
+		KlinesPath:          "/api/v3/klines",
+		TimePath:            "/api/v3/time",
+		StreamURL:           "wss://stream.binance.us:9443/stream",
+		KlinesLimit:         1000,
+		KlinesWeight:        1,
+		DefaultWeightBudget: 1000,
+	}
+	BinanceUSDMFutures = BinanceMarket{
+		Name:                "binance_usdm",
+		BaseURL:             "https://fapi.binance.com",
+		KlinesPath:          "/fapi/v1/klines",
+		TimePath:            "/fapi/v1/time",
+		FundingRatePath:     "/fapi/v1/fundingRate",
+		OpenInterestPath:    "/futures/data/openInterestHist",
+		StreamURL:           "wss://fstream.binance.com/stream",
+		KlinesLimit:         1500,
+		KlinesWeight:        10,
+		DefaultWeightBudget: 2000,
+	}
+	BinanceCOINMFutures = BinanceMarket{
+		Name:                "binance_coinm",
+		BaseURL:             "https://dapi.binance.com",
+		KlinesPath:          "/dapi/v1/klines",
+		TimePath:            "/dapi/v1/time",
+		FundingRatePath:     "/dapi/v1/fundingRate",
+		StreamURL:           "wss://dstream.binance.com/stream",
+		KlinesLimit:         1500,
+		KlinesWeight:        10,
+		MaxRangeMillis:      200 * 24 * 60 * 60 * 1000,
+		DefaultWeightBudget: 2000,
+	}
+)
+
+var BinanceMarkets = []BinanceMarket{
+	BinanceSpot,
+	BinanceSpotTestnet,
+	BinanceUS,
+	BinanceUSDMFutures,
+	BinanceCOINMFutures,
+}
+
+func BinanceMarketByName(name string) (BinanceMarket, bool) {
+	for _, market := range BinanceMarkets {
+		if market.Name == name {
+			return market, true
+		}
+	}
+	return BinanceMarket{}, false
+}
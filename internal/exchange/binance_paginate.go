@@ -6,18 +6,6 @@ import (
 	"fmt"
 )
 
-const (
-	binanceKlinesMaxLimit = 1000
-)
-
-func IntervalToMillis(interval string) (int64, error) {
-	// v0.1 only needs 4h, keep it strict to avoid mistakes
-	if interval == "4h" {
-		return 4 * 60 * 60 * 1000, nil
-	}
-	return 0, fmt.Errorf("unsupported interval: %s", interval)
-}
-
 func (client BinanceClient) FetchKlinesPaginated(
 	context context.Context,
 	symbol string,
@@ -26,27 +14,71 @@ func (client BinanceClient) FetchKlinesPaginated(
 	endTimeMillis int64,
 ) ([]candles.Candle, error) {
 
-	intervalMillis, err := candles.TimeframeToMillis(interval)
+	timeframe, err := candles.ParseTimeframe(interval)
 	if err != nil {
 		return nil, err
 	}
 
+	// The open-candle check uses the exchange clock, never the (possibly skewed) local one
+	nowMillis, err := client.serverNowMillis(context)
+	if err != nil {
+		return nil, fmt.Errorf("%s server time: %w", client.Name(), err)
+	}
+
+	market := client.market()
+
 	currentStartTimeMillis := startTimeMillis
+	if market.MaxRangeMillis > 0 && !timeframe.IsAligned(currentStartTimeMillis) {
+		// Ranged windows are counted in whole bars, so start on a boundary
+		currentStartTimeMillis = timeframe.Next(currentStartTimeMillis)
+	}
 	var allCandles []candles.Candle
 
 	for {
-		requestURL := BuildKlinesURL(symbol, interval, binanceKlinesMaxLimit, currentStartTimeMillis, endTimeMillis)
+		pageLimit := market.KlinesLimit
+		requestEndTimeMillis := endTimeMillis
+		if market.MaxRangeMillis > 0 {
+			// Ranged markets reject open-ended/too-wide windows; ask for as many whole bars as fit
+			pageEndTimeMillis := currentStartTimeMillis
+			bars := 0
+			for bars < market.KlinesLimit && timeframe.Next(pageEndTimeMillis)-currentStartTimeMillis <= market.MaxRangeMillis {
+				pageEndTimeMillis = timeframe.Next(pageEndTimeMillis)
+				bars++
+			}
+			pageLimit = bars
+			pageEndTimeMillis--
+			if requestEndTimeMillis <= 0 || requestEndTimeMillis > pageEndTimeMillis {
+				requestEndTimeMillis = pageEndTimeMillis
+			}
+		}
 
-		body, err := client.Get(context, requestURL)
+		requestURL := BuildKlinesURL(client.baseURL(), market.KlinesPath, symbol, interval, pageLimit, currentStartTimeMillis, requestEndTimeMillis)
+
+		body, err := client.GetWeighted(context, requestURL, market.KlinesWeight)
 		if err != nil {
 			return nil, err
 		}
 
-		pageCandles, err := ParseKlinesToCandles(body, "binance", symbol, interval)
+		pageCandles, err := ParseKlinesToCandles(body, client.Name(), symbol, interval, nowMillis)
 		if err != nil {
 			return nil, err
 		}
 
+		if market.MaxRangeMillis > 0 {
+			// Walk the ranged windows end to end: an empty or short window (before the listing,
+			// an exchange outage) says nothing about the windows after it
+			allCandles = append(allCandles, pageCandles...)
+			currentStartTimeMillis = requestEndTimeMillis + 1
+			lastMillis := endTimeMillis
+			if lastMillis <= 0 || lastMillis > nowMillis {
+				lastMillis = nowMillis
+			}
+			if currentStartTimeMillis > lastMillis {
+				break
+			}
+			continue
+		}
+
 		// Stop if no data returned
 		if len(pageCandles) == 0 {
 			break
@@ -56,7 +88,7 @@ func (client BinanceClient) FetchKlinesPaginated(
 
 		// Move start forward to avoid duplicates
 		lastCandle := pageCandles[len(pageCandles)-1]
-		nextStartTimeMillis := lastCandle.Timestamp + intervalMillis
+		nextStartTimeMillis := timeframe.Next(lastCandle.Timestamp)
 
 		// Safety: avoid infinite loop
 		if nextStartTimeMillis <= currentStartTimeMillis {
@@ -65,7 +97,7 @@ func (client BinanceClient) FetchKlinesPaginated(
 		currentStartTimeMillis = nextStartTimeMillis
 
 		// If less than max page size, we’re likely done
-		if len(pageCandles) < binanceKlinesMaxLimit {
+		if len(pageCandles) < pageLimit {
 			break
 		}
 
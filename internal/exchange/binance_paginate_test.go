@@ -0,0 +1,102 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// klineServer serves the COIN-M time and klines endpoints from a fixed set of daily bars,
+// honouring startTime, endTime and limit like Binance does.
+func klineServer(t *testing.T, serverTime int64, openTimes []int64) (*httptest.Server, *[][2]int64) {
+	t.Helper()
+	const day = 24 * 60 * 60 * 1000
+	var mutex sync.Mutex
+	var windows [][2]int64
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case BinanceCOINMFutures.TimePath:
+			fmt.Fprintf(writer, `{"serverTime":%d}`, serverTime)
+		case BinanceCOINMFutures.KlinesPath:
+			query := request.URL.Query()
+			start, _ := strconv.ParseInt(query.Get("startTime"), 10, 64)
+			end, _ := strconv.ParseInt(query.Get("endTime"), 10, 64)
+			limit, _ := strconv.Atoi(query.Get("limit"))
+			mutex.Lock()
+			windows = append(windows, [2]int64{start, end})
+			mutex.Unlock()
+
+			rows := [][]any{}
+			for _, open := range openTimes {
+				if open >= start && open <= end && len(rows) < limit {
+					rows = append(rows, []any{open, "1.0", "2.0", "0.5", "1.5", "10.0", open + day - 1, "15.0", 3, "5.0", "7.5", "0"})
+				}
+			}
+			_ = json.NewEncoder(writer).Encode(rows)
+		default:
+			http.NotFound(writer, request)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &windows
+}
+
+func TestFetchKlinesPaginatedWalksEveryRangedWindow(t *testing.T) {
+	const day = 24 * 60 * 60 * 1000
+	const start = 1577836800000 // 2020-01-01 00:00 UTC
+	// Listed on day 250 of the requested range, with a ten-day outage from day 300
+	var openTimes []int64
+	for i := 250; i < 600; i++ {
+		if i >= 300 && i < 310 {
+			continue
+		}
+		openTimes = append(openTimes, start+int64(i)*day)
+	}
+	end := int64(start + 450*day - 1)
+
+	server, windows := klineServer(t, start+1000*day, openTimes)
+	client := NewBinanceMarketClient(BinanceCOINMFutures, -1)
+	client.BaseURL = server.URL
+
+	got, err := client.FetchKlinesPaginated(context.Background(), "BTCUSD_PERP", "1d", start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Days 250-449 without the outage; the first (empty) and second (short) windows don't stop the walk
+	if want := 200 - 10; len(got) != want {
+		t.Fatalf("fetched %d candles, want %d", len(got), want)
+	}
+	if got[0].Timestamp != start+250*day || got[len(got)-1].Timestamp != start+449*day {
+		t.Errorf("fetched %d..%d, want days 250..449", got[0].Timestamp, got[len(got)-1].Timestamp)
+	}
+	for i, window := range *windows {
+		if window[1]-window[0] >= BinanceCOINMFutures.MaxRangeMillis {
+			t.Errorf("window %d spans %d ms, over the market cap", i, window[1]-window[0])
+		}
+	}
+	if len(*windows) != 3 {
+		t.Errorf("requested %d windows, want 3 (days 0-199, 200-399, 400-449)", len(*windows))
+	}
+}
+
+func TestFetchKlinesPaginatedRangedWindowsStopAtServerTime(t *testing.T) {
+	const day = 24 * 60 * 60 * 1000
+	const start = 1577836800000
+	// No end time: walk until the exchange clock, through a fully empty history
+	server, windows := klineServer(t, start+450*day, nil)
+	client := NewBinanceMarketClient(BinanceCOINMFutures, -1)
+	client.BaseURL = server.URL
+
+	got, err := client.FetchKlinesPaginated(context.Background(), "BTCUSD_PERP", "1d", start, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 || len(*windows) != 3 {
+		t.Fatalf("fetched %d candles in %d windows, want none in 3", len(got), len(*windows))
+	}
+}
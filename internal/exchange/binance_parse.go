@@ -7,7 +7,9 @@ import (
 	"strconv"
 )
 
-func ParseKlinesToCandles(body []byte, exchangeName, symbol, timeframe string) ([]candles.Candle, error) {
+// ParseKlinesToCandles converts a klines response into candles. A kline whose close time has not
+// passed at nowMillis is the still-forming bar and is marked IsFinal=false.
+func ParseKlinesToCandles(body []byte, exchangeName, symbol, timeframe string, nowMillis int64) ([]candles.Candle, error) {
 	var rows [][]interface{}
 	if err := json.Unmarshal(body, &rows); err != nil {
 		return nil, err
@@ -15,55 +17,83 @@ func ParseKlinesToCandles(body []byte, exchangeName, symbol, timeframe string) (
 
 	var result []candles.Candle
 
-	for _, r := range rows {
+	for i, r := range rows {
 		if len(r) < 7 {
-			return nil, fmt.Errorf("unexpected kline length: %d", len(r))
+			return nil, fmt.Errorf("kline row %d: unexpected length %d", i, len(r))
 		}
 
-		// IDEA: make a function that handles the conversion -> factory, modular
-		openTime := int64(r[0].(float64))
-		open, err := strconv.ParseFloat(r[1].(string), 64)
-		if err != nil {
-			return nil, err
-		}
-		high, err := strconv.ParseFloat(r[2].(string), 64)
-		if err != nil {
-			return nil, err
+		openTime, ok := r[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("kline row %d: open time is not a number: %v", i, r[0])
 		}
-		low, err := strconv.ParseFloat(r[3].(string), 64)
-		if err != nil {
-			return nil, err
+		closeTime, ok := r[6].(float64)
+		if !ok {
+			return nil, fmt.Errorf("kline row %d: close time is not a number: %v", i, r[6])
 		}
-		closeP, err := strconv.ParseFloat(r[4].(string), 64)
-		if err != nil {
-			return nil, err
-		}
-		vol, err := strconv.ParseFloat(r[5].(string), 64)
-		if err != nil {
-			return nil, err
+		// open, high, low, close, volume
+		values := make([]float64, 5)
+		for j := range values {
+			text, ok := r[j+1].(string)
+			if !ok {
+				return nil, fmt.Errorf("kline row %d: column %d is not a string: %v", i, j+1, r[j+1])
+			}
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("kline row %d: column %d: %w", i, j+1, err)
+			}
+			values[j] = value
 		}
-		closeTime := int64(r[6].(float64))
 
-		c := candles.Candle{
+		candle := candles.Candle{
 			Exchange:  exchangeName,
 			Symbol:    symbol,
 			Timeframe: timeframe,
-			Timestamp: openTime,
-			Open:      open,
-			High:      high,
-			Low:       low,
-			Close:     closeP,
-			Volume:    vol,
-			CloseTime: closeTime,
-			IsFinal:   true,
+			Timestamp: int64(openTime),
+			Open:      values[0],
+			High:      values[1],
+			Low:       values[2],
+			Close:     values[3],
+			Volume:    values[4],
+			CloseTime: int64(closeTime),
+			IsFinal:   int64(closeTime) < nowMillis,
+		}
+		if len(r) >= 11 {
+			quoteVolume, quoteOK := r[7].(string)
+			trades, tradesOK := r[8].(float64)
+			takerBuyBase, baseOK := r[9].(string)
+			takerBuyQuote, takerQuoteOK := r[10].(string)
+			if !quoteOK || !tradesOK || !baseOK || !takerQuoteOK {
+				return nil, fmt.Errorf("kline row %d: unexpected order-flow columns: %v", i, r[7:11])
+			}
+			if err := setKlineOrderFlow(&candle, quoteVolume, int64(trades), takerBuyBase, takerBuyQuote); err != nil {
+				return nil, fmt.Errorf("kline row %d: %w", i, err)
+			}
 		}
 
-		result = append(result, c)
+		result = append(result, candle)
 	}
 
 	return result, nil
 }
 
+// setKlineOrderFlow fills the order-flow fields from kline columns 7-10 (decimal strings and a
+// trade count), which every Binance kline format (REST, archive, stream) carries.
+func setKlineOrderFlow(candle *candles.Candle, quoteVolume string, trades int64, takerBuyBaseVolume string, takerBuyQuoteVolume string) error {
+	values := make([]float64, 3)
+	for i, text := range []string{quoteVolume, takerBuyBaseVolume, takerBuyQuoteVolume} {
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("order-flow column: %w", err)
+		}
+		values[i] = value
+	}
+	candle.QuoteVolume = &values[0]
+	candle.Trades = &trades
+	candle.TakerBuyBaseVolume = &values[1]
+	candle.TakerBuyQuoteVolume = &values[2]
+	return nil
+}
+
 /* temporary
 func ParseFirstKlineToCandle(body []byte, exchangeName, symbol, timeframe string) (candles.Candle, error) {
 	var rows [][]interface{}
@@ -0,0 +1,50 @@
+package exchange
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKlinesToCandles(t *testing.T) {
+	body := `[[1709251200000,"61130.98","61600.00","61000.01","61500.00","20.5",1709265599999,"1262.5",42,"6.0","606.25","0"]]`
+	got, err := ParseKlinesToCandles([]byte(body), "binance", "BTCUSDT", "4h", 1709265600000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d candles, want 1", len(got))
+	}
+	candle := got[0]
+	if candle.Timestamp != 1709251200000 || candle.CloseTime != 1709265599999 || !candle.IsFinal ||
+		candle.Open != 61130.98 || candle.Close != 61500 || candle.Volume != 20.5 {
+		t.Errorf("candle = %+v", candle)
+	}
+	if candle.Trades == nil || *candle.Trades != 42 || candle.QuoteVolume == nil || *candle.QuoteVolume != 1262.5 {
+		t.Errorf("order flow = trades %v quote volume %v, want 42 and 1262.5", candle.Trades, candle.QuoteVolume)
+	}
+}
+
+func TestParseKlinesToCandlesRejectsMalformedRows(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"short row", `[[1709251200000,"1","2","0.5","1.5","10"]]`, "kline row 0: unexpected length 6"},
+		{"string open time", `[["1709251200000","1","2","0.5","1.5","10",1709265599999]]`, "kline row 0: open time is not a number"},
+		{"null close time", `[[1709251200000,"1","2","0.5","1.5","10",null]]`, "kline row 0: close time is not a number"},
+		{"numeric price", `[[1709251200000,1,"2","0.5","1.5","10",1709265599999]]`, "kline row 0: column 1 is not a string"},
+		{"bad volume", `[[1709251200000,"1","2","0.5","1.5","x",1709265599999]]`, "kline row 0: column 5"},
+		{"bad order flow", `[[1709251200000,"1","2","0.5","1.5","10",1709265599999,15,3,"5","7.5","0"]]`, "kline row 0: unexpected order-flow columns"},
+		{"second row", `[[1709251200000,"1","2","0.5","1.5","10",1709265599999],[]]`, "kline row 1: unexpected length 0"},
+		{"error object", `{"code":-1121,"msg":"Invalid symbol."}`, "cannot unmarshal"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseKlinesToCandles([]byte(test.body), "binance", "BTCUSDT", "4h", 0)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("err = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
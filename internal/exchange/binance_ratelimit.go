@@ -0,0 +1,104 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	binanceDefaultRequestWeight = 1
+
+	binanceUsedWeightHeader = "X-MBX-USED-WEIGHT-1M"
+)
+
+// weightLimiter tracks request weight in Binance's one-minute windows. It is shared (by pointer)
+// between copies of a BinanceClient so concurrent fetches draw from the same budget.
+type weightLimiter struct {
+	mutex sync.Mutex
+
+	budget int
+
+	windowStart time.Time
+	usedWeight  int
+
+	// Set from Retry-After on 429/418 so every caller pauses, not just the one that got rejected
+	pausedUntil time.Time
+
+	now func() time.Time // time.Now; tests drive it to control the window
+}
+
+func newWeightLimiter(budget int) *weightLimiter {
+	return &weightLimiter{budget: budget, now: time.Now}
+}
+
+// reserve blocks until weight fits into the current window's budget and records it as used.
+func (limiter *weightLimiter) reserve(ctx context.Context, weight int) error {
+	for {
+		wait := limiter.tryReserve(limiter.now(), weight)
+		if wait <= 0 {
+			return nil
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func (limiter *weightLimiter) tryReserve(now time.Time, weight int) time.Duration {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	if now.Before(limiter.pausedUntil) {
+		return limiter.pausedUntil.Sub(now)
+	}
+
+	limiter.rollWindow(now)
+
+	// A single request heavier than the whole budget still has to go through eventually
+	if limiter.usedWeight > 0 && limiter.usedWeight+weight > limiter.budget {
+		return limiter.windowStart.Add(time.Minute).Sub(now)
+	}
+
+	limiter.usedWeight += weight
+	return 0
+}
+
+// observe syncs the local counter with the weight Binance reports having counted for this IP.
+func (limiter *weightLimiter) observe(header http.Header) {
+	value := header.Get(binanceUsedWeightHeader)
+	if value == "" {
+		return
+	}
+	usedWeight, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.rollWindow(limiter.now())
+	if usedWeight > limiter.usedWeight {
+		limiter.usedWeight = usedWeight
+	}
+}
+
+func (limiter *weightLimiter) pause(until time.Time) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	if until.After(limiter.pausedUntil) {
+		limiter.pausedUntil = until
+	}
+}
+
+func (limiter *weightLimiter) rollWindow(now time.Time) {
+	currentWindow := now.Truncate(time.Minute)
+	if !currentWindow.Equal(limiter.windowStart) {
+		limiter.windowStart = currentWindow
+		limiter.usedWeight = 0
+	}
+}
@@ -0,0 +1,83 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWeightLimiterBudget(t *testing.T) {
+	limiter := newWeightLimiter(10)
+	windowStart := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := windowStart.Add(10 * time.Second)
+
+	if wait := limiter.tryReserve(now, 6); wait != 0 {
+		t.Fatalf("first reserve waited %v", wait)
+	}
+	if wait := limiter.tryReserve(now, 4); wait != 0 {
+		t.Fatalf("reserve up to the budget waited %v", wait)
+	}
+	if wait := limiter.tryReserve(now, 1); wait != 50*time.Second {
+		t.Fatalf("reserve over budget waits %v, want the 50s left in the window", wait)
+	}
+	if wait := limiter.tryReserve(windowStart.Add(time.Minute), 1); wait != 0 {
+		t.Fatalf("reserve in the next window waited %v", wait)
+	}
+}
+
+func TestWeightLimiterLetsOversizedRequestThroughEmptyWindow(t *testing.T) {
+	limiter := newWeightLimiter(10)
+	if wait := limiter.tryReserve(time.Date(2024, 3, 1, 12, 0, 5, 0, time.UTC), 50); wait != 0 {
+		t.Fatalf("oversized request in an empty window waited %v", wait)
+	}
+}
+
+func TestWeightLimiterPause(t *testing.T) {
+	limiter := newWeightLimiter(10)
+	now := time.Date(2024, 3, 1, 12, 0, 5, 0, time.UTC)
+
+	limiter.pause(now.Add(30 * time.Second))
+	limiter.pause(now.Add(10 * time.Second)) // an earlier pause never shortens a longer one
+	if wait := limiter.tryReserve(now, 1); wait != 30*time.Second {
+		t.Fatalf("reserve while paused waits %v, want 30s", wait)
+	}
+	if wait := limiter.tryReserve(now.Add(30*time.Second), 1); wait != 0 {
+		t.Fatalf("reserve after the pause waited %v", wait)
+	}
+}
+
+func TestBinanceClientThrottlesOnUsedWeightHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set(binanceUsedWeightHeader, "95")
+		_, _ = writer.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewBinanceMarketClient(BinanceSpot, 100)
+	client.BaseURL = server.URL
+	// 59.9s into a window: with the wall clock the request and the checks below could land in
+	// different minutes
+	windowStart := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := windowStart.Add(59*time.Second + 900*time.Millisecond)
+	client.limiter.now = func() time.Time { return now }
+
+	if _, err := client.GetWeighted(context.Background(), server.URL, 2); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	// The server counted 95 of the 100 budget (other processes on the same IP), not just our 2
+	if wait := client.limiter.tryReserve(now, 5); wait != 0 {
+		t.Fatalf("reserve within the reported budget waited %v", wait)
+	}
+	if wait := client.limiter.tryReserve(now, 1); wait != 100*time.Millisecond {
+		t.Fatalf("reserve over the reported budget waits %v, want the 100ms left in the window", wait)
+	}
+
+	// The next window starts from zero until Binance reports otherwise
+	now = windowStart.Add(time.Minute)
+	if wait := client.limiter.tryReserve(now, 100); wait != 0 {
+		t.Fatalf("reserve in the next window waited %v", wait)
+	}
+}
@@ -0,0 +1,47 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+func init() {
+	for _, market := range BinanceMarkets {
+		market := market
+		RegisterCandleSource(market.Name, func(options SourceOptions) CandleSource {
+			client := NewBinanceMarketClient(market, options.WeightBudget)
+			client.HTTPClient = options.HTTPClient
+			client.BaseURL = options.BaseURL
+			return client
+		})
+	}
+}
+
+var binanceTimeframes = []string{"1m", "3m", "5m", "15m", "30m", "1h", "2h", "4h", "6h", "8h", "12h", "1d", "1w", "1M"}
+
+func (client BinanceClient) Name() string {
+	return client.market().Name
+}
+
+func (client BinanceClient) Timeframes() []string {
+	return binanceTimeframes
+}
+
+func (client BinanceClient) ReportsOrderFlow() bool {
+	return true
+}
+
+func (client BinanceClient) FetchCandles(
+	ctx context.Context,
+	symbol string,
+	timeframe string,
+	startTimeMillis int64,
+	endTimeMillis int64,
+) ([]candles.Candle, error) {
+	if !SupportsTimeframe(client, timeframe) {
+		return nil, fmt.Errorf("%s does not support timeframe %q", client.Name(), timeframe)
+	}
+	return client.FetchKlinesPaginated(ctx, symbol, timeframe, startTimeMillis, endTimeMillis)
+}
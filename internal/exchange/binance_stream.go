@@ -0,0 +1,283 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+type StreamSubscription struct {
+	Symbol    string
+	Timeframe string
+}
+
+// BinanceKlineStream consumes Binance combined kline streams and reconnects with backoff.
+type BinanceKlineStream struct {
+	Market BinanceMarket
+	// StreamURL overrides Market.StreamURL (e.g. a local stand-in server)
+	StreamURL string
+
+	Subscriptions []StreamSubscription
+	Retry         RetryPolicy
+
+	Dialer *websocket.Dialer
+
+	// ReadTimeout drops a connection that delivers nothing (no message, ping or pong) for this
+	// long, so a half-open TCP connection is reconnected instead of blocking forever
+	// (0 = defaultStreamReadTimeout). Pings are sent every ReadTimeout/3 to keep it alive.
+	ReadTimeout time.Duration
+
+	// OnConnect runs after every successful (re)connect, before messages are read. Use it to
+	// backfill over REST whatever closed while the stream was down; an error drops the
+	// connection and is retried with backoff like a failed dial.
+	OnConnect func(ctx context.Context, reconnect bool) error
+	// OnClosedCandle receives each kline whose bar has closed (x=true); an error reconnects
+	// (the next OnConnect backfill covers the candle again).
+	OnClosedCandle func(ctx context.Context, candle candles.Candle) error
+	// OnDisconnect is informational (logging); Run keeps retrying after it.
+	OnDisconnect func(err error, retryIn time.Duration)
+	// OnBadMessage is informational (logging): a frame that is not valid kline JSON is skipped.
+	OnBadMessage func(body []byte, err error)
+}
+
+const defaultStreamReadTimeout = 3 * time.Minute
+
+// StreamFatal marks a callback error that should stop Run instead of reconnecting.
+func StreamFatal(err error) error {
+	return streamFatalError{err: err}
+}
+
+type streamFatalError struct {
+	err error
+}
+
+func (e streamFatalError) Error() string { return e.err.Error() }
+func (e streamFatalError) Unwrap() error { return e.err }
+
+type binanceStreamEnvelope struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Binance uses keys that differ only by case (e/E, l/L, v/V, q/Q). encoding/json matches keys
+// case-insensitively, so every such key needs its own field or it overwrites its twin.
+type binanceKlineEvent struct {
+	EventType string `json:"e"`
+	EventTime int64  `json:"E"`
+	Symbol    string `json:"s"`
+	Kline     struct {
+		OpenTime            int64  `json:"t"`
+		CloseTime           int64  `json:"T"`
+		Symbol              string `json:"s"`
+		Interval            string `json:"i"`
+		FirstTradeID        int64  `json:"f"`
+		LastTradeID         int64  `json:"L"`
+		Open                string `json:"o"`
+		Close               string `json:"c"`
+		High                string `json:"h"`
+		Low                 string `json:"l"`
+		Volume              string `json:"v"`
+		Trades              int64  `json:"n"`
+		IsClosed            bool   `json:"x"`
+		QuoteVolume         string `json:"q"`
+		TakerBuyBaseVolume  string `json:"V"`
+		TakerBuyQuoteVolume string `json:"Q"`
+		Ignore              string `json:"B"`
+	} `json:"k"`
+}
+
+// BuildKlineStreamURL builds a combined-stream URL such as .../stream?streams=btcusdt@kline_4h.
+func BuildKlineStreamURL(streamURL string, subscriptions []StreamSubscription) string {
+	streams := make([]string, len(subscriptions))
+	for i, subscription := range subscriptions {
+		streams[i] = strings.ToLower(subscription.Symbol) + "@kline_" + subscription.Timeframe
+	}
+	// Stream names are not query-escaped by Binance clients ("/" and "@" are expected verbatim)
+	return streamURL + "?streams=" + strings.Join(streams, "/")
+}
+
+// ParseKlineStreamMessage decodes a combined-stream (or raw) kline message. ok is false for
+// messages that are not kline events (e.g. subscription acks).
+func ParseKlineStreamMessage(body []byte, exchangeName string) (candle candles.Candle, ok bool, err error) {
+	payload := body
+	var envelope binanceStreamEnvelope
+	if err := json.Unmarshal(body, &envelope); err == nil && len(envelope.Data) > 0 {
+		payload = envelope.Data
+	}
+
+	var event binanceKlineEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return candles.Candle{}, false, err
+	}
+	if event.EventType != "kline" {
+		return candles.Candle{}, false, nil
+	}
+
+	values := make([]float64, 5)
+	for i, text := range []string{event.Kline.Open, event.Kline.High, event.Kline.Low, event.Kline.Close, event.Kline.Volume} {
+		values[i], err = strconv.ParseFloat(text, 64)
+		if err != nil {
+			return candles.Candle{}, false, fmt.Errorf("kline %s %s t=%d: %w", event.Kline.Symbol, event.Kline.Interval, event.Kline.OpenTime, err)
+		}
+	}
+
+	candle = candles.Candle{
+		Exchange:  exchangeName,
+		Symbol:    event.Kline.Symbol,
+		Timeframe: event.Kline.Interval,
+		Timestamp: event.Kline.OpenTime,
+		Open:      values[0],
+		High:      values[1],
+		Low:       values[2],
+		Close:     values[3],
+		Volume:    values[4],
+		CloseTime: event.Kline.CloseTime,
+		IsFinal:   event.Kline.IsClosed,
+	}
+	if err := setKlineOrderFlow(&candle, event.Kline.QuoteVolume, event.Kline.Trades, event.Kline.TakerBuyBaseVolume, event.Kline.TakerBuyQuoteVolume); err != nil {
+		return candles.Candle{}, false, fmt.Errorf("kline %s %s t=%d: %w", event.Kline.Symbol, event.Kline.Interval, event.Kline.OpenTime, err)
+	}
+	return candle, true, nil
+}
+
+// Run connects and delivers closed candles until ctx is cancelled or a callback returns a
+// StreamFatal error. Dropped or silent connections (including Binance's 24h forced disconnect)
+// and failed callbacks are retried with the jittered backoff of Retry; the attempt counter
+// resets once a connection delivers data.
+func (stream BinanceKlineStream) Run(ctx context.Context) error {
+	if len(stream.Subscriptions) == 0 {
+		return fmt.Errorf("no stream subscriptions")
+	}
+	streamURL := stream.StreamURL
+	if streamURL == "" {
+		streamURL = stream.Market.StreamURL
+	}
+	if _, err := url.Parse(streamURL); err != nil || streamURL == "" {
+		return fmt.Errorf("invalid stream url %q", streamURL)
+	}
+	requestURL := BuildKlineStreamURL(streamURL, stream.Subscriptions)
+
+	dialer := stream.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+
+	attempt := 0
+	connectedBefore := false
+	for {
+		receivedData, err := stream.runConnection(ctx, dialer, requestURL, connectedBefore)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var fatalErr streamFatalError
+		if errors.As(err, &fatalErr) {
+			return fatalErr.err
+		}
+
+		if receivedData {
+			attempt = 0
+			connectedBefore = true
+		}
+		wait := stream.Retry.Backoff(attempt)
+		attempt++
+		if stream.OnDisconnect != nil {
+			stream.OnDisconnect(err, wait)
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// runConnection serves one WebSocket session until it fails; a StreamFatal error from a
+// callback comes back unchanged so Run can stop on it.
+func (stream BinanceKlineStream) runConnection(ctx context.Context, dialer *websocket.Dialer, requestURL string, reconnect bool) (receivedData bool, err error) {
+	connection, _, err := dialer.DialContext(ctx, requestURL, nil)
+	if err != nil {
+		return false, err
+	}
+	defer connection.Close()
+
+	readTimeout := stream.ReadTimeout
+	if readTimeout <= 0 {
+		readTimeout = defaultStreamReadTimeout
+	}
+	extendDeadline := func() {
+		_ = connection.SetReadDeadline(time.Now().Add(readTimeout))
+	}
+	extendDeadline()
+	connection.SetPongHandler(func(string) error {
+		extendDeadline()
+		return nil
+	})
+	connection.SetPingHandler(func(data string) error {
+		extendDeadline()
+		err := connection.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+		return err
+	})
+
+	// Keep the connection alive with pings, and unblock ReadMessage when the caller cancels
+	sessionDone := make(chan struct{})
+	defer close(sessionDone)
+	go func() {
+		ticker := time.NewTicker(readTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				_ = connection.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+				connection.Close()
+				return
+			case <-ticker.C:
+				_ = connection.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second))
+			case <-sessionDone:
+				return
+			}
+		}
+	}()
+
+	if stream.OnConnect != nil {
+		if err := stream.OnConnect(ctx, reconnect); err != nil {
+			return false, fmt.Errorf("on connect: %w", err)
+		}
+		// A long backfill must not count against the read timeout
+		extendDeadline()
+	}
+
+	for {
+		_, body, err := connection.ReadMessage()
+		if err != nil {
+			return receivedData, err
+		}
+		receivedData = true
+		extendDeadline()
+
+		candle, ok, err := ParseKlineStreamMessage(body, stream.Market.Name)
+		if err != nil {
+			if stream.OnBadMessage != nil {
+				stream.OnBadMessage(body, err)
+			}
+			continue
+		}
+		if !ok || !candle.IsFinal {
+			continue
+		}
+		if stream.OnClosedCandle != nil {
+			if err := stream.OnClosedCandle(ctx, candle); err != nil {
+				return receivedData, fmt.Errorf("closed candle %s %s t=%d: %w", candle.Symbol, candle.Timeframe, candle.Timestamp, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+const fourHoursMillis = 4 * 60 * 60 * 1000
+
+func closedKlineMessage(openTime int64) []byte {
+	return []byte(fmt.Sprintf(`{"stream":"btcusdt@kline_4h","data":{"e":"kline","E":%d,"s":"BTCUSDT","k":{"t":%d,"T":%d,"s":"BTCUSDT","i":"4h","f":1,"L":2,"o":"100.0","c":"101.5","h":"102.0","l":"99.5","v":"12.5","n":42,"x":true,"q":"1262.5","V":"6.0","Q":"606.0","B":"0"}}}`,
+		openTime+fourHoursMillis, openTime, openTime+fourHoursMillis-1))
+}
+
+// streamServer runs a WebSocket server that hands each connection (numbered from 1) to serve.
+func streamServer(t *testing.T, serve func(number int, connection *websocket.Conn)) *httptest.Server {
+	t.Helper()
+	var connections atomic.Int32
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		connection, err := upgrader.Upgrade(writer, request, nil)
+		if err != nil {
+			return
+		}
+		defer connection.Close()
+		serve(int(connections.Add(1)), connection)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testStream(server *httptest.Server) BinanceKlineStream {
+	return BinanceKlineStream{
+		Market:        BinanceSpot,
+		StreamURL:     "ws" + strings.TrimPrefix(server.URL, "http") + "/stream",
+		Subscriptions: []StreamSubscription{{Symbol: "BTCUSDT", Timeframe: "4h"}},
+		Retry:         RetryPolicy{MaxRetries: 0, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	}
+}
+
+// drain keeps reading (answering pings) until the client goes away.
+func drain(connection *websocket.Conn) {
+	for {
+		if _, _, err := connection.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestBinanceKlineStreamReconnectsAndBackfills(t *testing.T) {
+	const firstOpen = 1709251200000
+	secondOpen := int64(firstOpen + fourHoursMillis)
+
+	server := streamServer(t, func(number int, connection *websocket.Conn) {
+		switch number {
+		case 1:
+			// Deliver one candle, then drop the connection without a close frame
+			_ = connection.WriteMessage(websocket.TextMessage, closedKlineMessage(firstOpen))
+		case 2:
+			// The client's backfill fails on this connection; wait for it to hang up
+			drain(connection)
+		default:
+			_ = connection.WriteMessage(websocket.TextMessage, []byte(`{"stream":"btcusdt@kline_4h","data":{"e":"kline","k":{"o":`))
+			_ = connection.WriteMessage(websocket.TextMessage, closedKlineMessage(secondOpen))
+			drain(connection)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var mutex sync.Mutex
+	var connects []bool
+	var received []candles.Candle
+	var disconnects []error
+	var badMessages int
+
+	stream := testStream(server)
+	stream.OnConnect = func(ctx context.Context, reconnect bool) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		connects = append(connects, reconnect)
+		if len(connects) == 2 {
+			return errors.New("rest backfill: 503 Service Unavailable")
+		}
+		return nil
+	}
+	stream.OnClosedCandle = func(ctx context.Context, candle candles.Candle) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		received = append(received, candle)
+		if len(received) == 2 {
+			cancel()
+		}
+		return nil
+	}
+	stream.OnDisconnect = func(err error, retryIn time.Duration) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		disconnects = append(disconnects, err)
+	}
+	stream.OnBadMessage = func(body []byte, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		badMessages++
+	}
+
+	if err := stream.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(received) != 2 || received[0].Timestamp != firstOpen || received[1].Timestamp != secondOpen {
+		t.Fatalf("received %+v, want candles at %d and %d", received, firstOpen, secondOpen)
+	}
+	if want := []bool{false, true, true}; fmt.Sprint(connects) != fmt.Sprint(want) {
+		t.Errorf("OnConnect reconnect flags = %v, want %v", connects, want)
+	}
+	if len(disconnects) != 2 {
+		t.Fatalf("disconnects = %v, want the dropped connection and the failed backfill", disconnects)
+	}
+	if !strings.Contains(disconnects[1].Error(), "rest backfill") {
+		t.Errorf("second disconnect = %v, want the backfill failure", disconnects[1])
+	}
+	if badMessages != 1 {
+		t.Errorf("bad messages = %d, want 1", badMessages)
+	}
+}
+
+func TestBinanceKlineStreamReconnectsSilentConnection(t *testing.T) {
+	var silentDone sync.Once
+	silent := make(chan struct{})
+	server := streamServer(t, func(number int, connection *websocket.Conn) {
+		if number == 1 {
+			// Half-open: never read (so pings go unanswered) and never write
+			<-silent
+			return
+		}
+		_ = connection.WriteMessage(websocket.TextMessage, closedKlineMessage(1709251200000))
+		drain(connection)
+	})
+	defer silentDone.Do(func() { close(silent) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var disconnects []error
+	stream := testStream(server)
+	stream.ReadTimeout = 150 * time.Millisecond
+	stream.OnClosedCandle = func(ctx context.Context, candle candles.Candle) error {
+		cancel()
+		return nil
+	}
+	stream.OnDisconnect = func(err error, retryIn time.Duration) {
+		disconnects = append(disconnects, err)
+		silentDone.Do(func() { close(silent) })
+	}
+
+	if err := stream.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled after reconnecting", err)
+	}
+	if len(disconnects) != 1 || !strings.Contains(disconnects[0].Error(), "timeout") {
+		t.Fatalf("disconnects = %v, want one read timeout", disconnects)
+	}
+}
+
+func TestBinanceKlineStreamStopsOnFatalCallbackError(t *testing.T) {
+	server := streamServer(t, func(number int, connection *websocket.Conn) {
+		_ = connection.WriteMessage(websocket.TextMessage, closedKlineMessage(1709251200000))
+		drain(connection)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	diskFull := errors.New("database or disk is full")
+	stream := testStream(server)
+	stream.OnClosedCandle = func(ctx context.Context, candle candles.Candle) error {
+		return StreamFatal(diskFull)
+	}
+	stream.OnDisconnect = func(err error, retryIn time.Duration) {
+		t.Errorf("reconnecting after a fatal callback error: %v", err)
+	}
+
+	if err := stream.Run(ctx); !errors.Is(err, diskFull) {
+		t.Fatalf("Run = %v, want %v", err, diskFull)
+	}
+}
@@ -5,10 +5,7 @@ import (
 	"strconv"
 )
 
-func BuildKlinesURL(symbol string, interval string, limit int, startTimeMillis int64, endTimeMillis int64) string {
-	baseURL := "https://api.binance.com"
-	path := "/api/v3/klines"
-
+func BuildKlinesURL(baseURL string, path string, symbol string, interval string, limit int, startTimeMillis int64, endTimeMillis int64) string {
 	query := url.Values{}
 	query.Set("symbol", symbol)
 	query.Set("interval", interval)
@@ -23,3 +20,33 @@ func BuildKlinesURL(symbol string, interval string, limit int, startTimeMillis i
 
 	return baseURL + path + "?" + query.Encode()
 }
+
+func BuildFundingRateURL(baseURL string, path string, symbol string, limit int, startTimeMillis int64, endTimeMillis int64) string {
+	query := url.Values{}
+	query.Set("symbol", symbol)
+	query.Set("limit", strconv.Itoa(limit))
+
+	if startTimeMillis > 0 {
+		query.Set("startTime", strconv.FormatInt(startTimeMillis, 10))
+	}
+	if endTimeMillis > 0 {
+		query.Set("endTime", strconv.FormatInt(endTimeMillis, 10))
+	}
+
+	return baseURL + path + "?" + query.Encode()
+}
+
+func BuildOpenInterestURL(baseURL string, path string, symbol string, period string, limit int, startTimeMillis int64, endTimeMillis int64) string {
+	query := url.Values{}
+	query.Set("symbol", symbol)
+	query.Set("period", period)
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("startTime", strconv.FormatInt(startTimeMillis, 10))
+	query.Set("endTime", strconv.FormatInt(endTimeMillis, 10))
+
+	return baseURL + path + "?" + query.Encode()
+}
+
+func BuildServerTimeURL(baseURL string, path string) string {
+	return baseURL + path
+}
@@ -0,0 +1,129 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+const (
+	coinbaseCandlesMaxLimit = 300
+)
+
+func init() {
+	RegisterCandleSource("coinbase", func(options SourceOptions) CandleSource {
+		client := NewCoinbaseClient()
+		client.HTTPClient = options.HTTPClient
+		if options.BaseURL != "" {
+			client.BaseURL = options.BaseURL
+		}
+		return client
+	})
+}
+
+// Coinbase Exchange only serves these granularities (no 4h).
+var coinbaseGranularitySeconds = map[string]int64{
+	"1m":  60,
+	"5m":  300,
+	"15m": 900,
+	"1h":  3600,
+	"6h":  21600,
+	"1d":  86400,
+}
+
+var coinbaseTimeframes = []string{"1m", "5m", "15m", "1h", "6h", "1d"}
+
+type CoinbaseClient struct {
+	HTTPClient *http.Client
+	BaseURL    string
+
+	Retry RetryPolicy
+}
+
+func NewCoinbaseClient() CoinbaseClient {
+	return CoinbaseClient{HTTPClient: http.DefaultClient, BaseURL: coinbaseBaseURL, Retry: DefaultRetryPolicy()}
+}
+
+func (client CoinbaseClient) Get(ctx context.Context, requestURL string) ([]byte, error) {
+	getter := retryingGetter{
+		httpClient:   client.HTTPClient,
+		policy:       client.Retry,
+		exchangeName: "coinbase",
+		// Coinbase rejects requests without a User-Agent
+		header: http.Header{"User-Agent": []string{"btc-4h-prediction-model"}},
+	}
+	return getter.get(ctx, requestURL)
+}
+
+func (client CoinbaseClient) Name() string {
+	return "coinbase"
+}
+
+func (client CoinbaseClient) Timeframes() []string {
+	return coinbaseTimeframes
+}
+
+func (client CoinbaseClient) ReportsOrderFlow() bool {
+	return false
+}
+
+// FetchCandles walks [start, end] forward in windows of coinbaseCandlesMaxLimit buckets.
+// Empty windows are skipped rather than treated as the end, since Coinbase returns nothing
+// for ranges before a product was listed. endTimeMillis <= 0 means "up to now".
+func (client CoinbaseClient) FetchCandles(
+	ctx context.Context,
+	symbol string,
+	timeframe string,
+	startTimeMillis int64,
+	endTimeMillis int64,
+) ([]candles.Candle, error) {
+	granularitySeconds, ok := coinbaseGranularitySeconds[timeframe]
+	if !ok {
+		return nil, fmt.Errorf("%s does not support timeframe %q", client.Name(), timeframe)
+	}
+	intervalMillis := granularitySeconds * 1000
+
+	nowMillis := time.Now().UnixMilli()
+	if endTimeMillis <= 0 || endTimeMillis > nowMillis {
+		endTimeMillis = nowMillis
+	}
+
+	// Coinbase buckets are aligned to the granularity; start at the bucket containing startTime
+	windowStart := startTimeMillis - startTimeMillis%intervalMillis
+	var allCandles []candles.Candle
+
+	for windowStart <= endTimeMillis {
+		windowEnd := windowStart + (coinbaseCandlesMaxLimit-1)*intervalMillis
+		if windowEnd > endTimeMillis {
+			windowEnd = endTimeMillis
+		}
+
+		body, err := client.Get(ctx, BuildCoinbaseCandlesURL(client.BaseURL, symbol, granularitySeconds, windowStart, windowEnd))
+		if err != nil {
+			return nil, err
+		}
+
+		pageCandles, err := ParseCoinbaseCandles(body, client.Name(), symbol, timeframe, intervalMillis, nowMillis)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, candle := range pageCandles {
+			if candle.Timestamp < startTimeMillis || candle.Timestamp > endTimeMillis {
+				continue
+			}
+			// Windows are inclusive on both ends; drop the boundary duplicate
+			if len(allCandles) > 0 && candle.Timestamp <= allCandles[len(allCandles)-1].Timestamp {
+				continue
+			}
+			allCandles = append(allCandles, candle)
+		}
+
+		windowStart = windowEnd + intervalMillis
+	}
+
+	return allCandles, nil
+}
@@ -0,0 +1,53 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+// ParseCoinbaseCandles converts a Coinbase Exchange candles response into candles.
+// Coinbase rows are [time(s), low, high, open, close, volume] as JSON numbers, newest first;
+// the result is sorted by open time. There is no close time in the payload, so it is derived
+// with Binance's convention (open + interval - 1ms).
+func ParseCoinbaseCandles(body []byte, exchangeName, symbol, timeframe string, intervalMillis int64, nowMillis int64) ([]candles.Candle, error) {
+	var rows [][]float64
+	if err := json.Unmarshal(body, &rows); err != nil {
+		var apiError struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiError) == nil && apiError.Message != "" {
+			return nil, fmt.Errorf("coinbase error: %s", apiError.Message)
+		}
+		return nil, err
+	}
+
+	result := make([]candles.Candle, 0, len(rows))
+	for _, r := range rows {
+		if len(r) < 6 {
+			return nil, fmt.Errorf("unexpected coinbase candle length: %d", len(r))
+		}
+
+		openTime := int64(r[0]) * 1000
+		closeTime := openTime + intervalMillis - 1
+
+		result = append(result, candles.Candle{
+			Exchange:  exchangeName,
+			Symbol:    symbol,
+			Timeframe: timeframe,
+			Timestamp: openTime,
+			Low:       r[1],
+			High:      r[2],
+			Open:      r[3],
+			Close:     r[4],
+			Volume:    r[5],
+			CloseTime: closeTime,
+			IsFinal:   closeTime < nowMillis,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp < result[j].Timestamp })
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package exchange
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestParseCoinbaseCandles(t *testing.T) {
+	const sixHours = 6 * 60 * 60 * 1000
+	// The newest bar (opened 2024-03-01 12:00 UTC) is still forming at this time
+	now := int64(1709300000000)
+
+	got, err := ParseCoinbaseCandles(readFixture(t, "coinbase_candles_btc-usd_6h.json"), "coinbase", "BTC-USD", "6h", sixHours, now)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	// Rows arrive newest first as [time, low, high, open, close, volume]
+	want := []candles.Candle{
+		{Timestamp: 1709251200000, Open: 61130.98, High: 61600, Low: 61000.01, Close: 61500, Volume: 1500.25, CloseTime: 1709272799999, IsFinal: true},
+		{Timestamp: 1709272800000, Open: 61500, High: 61950.5, Low: 60900, Close: 61820.12, Volume: 987.65, CloseTime: 1709294399999, IsFinal: true},
+		{Timestamp: 1709294400000, Open: 61820.12, High: 62450.01, Low: 61100.5, Close: 62001.99, Volume: 1234.5678, CloseTime: 1709315999999, IsFinal: false},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d candles, want %d", len(got), len(want))
+	}
+	for i := range want {
+		want[i].Exchange, want[i].Symbol, want[i].Timeframe = "coinbase", "BTC-USD", "6h"
+		if got[i] != want[i] {
+			t.Errorf("candle %d:\n got  %+v\n want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseCoinbaseCandlesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"api error", string(readFixture(t, "coinbase_candles_error.json")), "coinbase error: NotFound"},
+		{"short row", `[[1709251200,61000.01,61600,61130.98,61500]]`, "unexpected coinbase candle length: 5"},
+		{"not json", `<html>`, "invalid character"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseCoinbaseCandles([]byte(test.body), "coinbase", "BTC-USD", "6h", 6*60*60*1000, 0)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("err = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package exchange
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const coinbaseBaseURL = "https://api.exchange.coinbase.com"
+
+// BuildCoinbaseCandlesURL builds a Coinbase Exchange candles request. start/end are inclusive
+// and must not span more than coinbaseCandlesMaxLimit buckets.
+func BuildCoinbaseCandlesURL(baseURL string, productID string, granularitySeconds int64, startTimeMillis int64, endTimeMillis int64) string {
+	query := url.Values{}
+	query.Set("granularity", strconv.FormatInt(granularitySeconds, 10))
+	query.Set("start", time.UnixMilli(startTimeMillis).UTC().Format(time.RFC3339))
+	query.Set("end", time.UnixMilli(endTimeMillis).UTC().Format(time.RFC3339))
+
+	return baseURL + "/products/" + url.PathEscape(productID) + "/candles?" + query.Encode()
+}
@@ -0,0 +1,189 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt (0 disables retrying).
+	MaxRetries int
+
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:  6,
+		BaseBackoff: 500 * time.Millisecond,
+		MaxBackoff:  60 * time.Second,
+	}
+}
+
+// Backoff returns the jittered exponential delay before retry number attempt (0-based):
+// a random duration in [d/2, d] where d = BaseBackoff * 2^attempt capped at MaxBackoff.
+func (policy RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := policy.BaseBackoff
+	for i := 0; i < attempt && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// isRetryableStatus covers rate limiting (429), IP bans (418) and server-side failures.
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests ||
+		statusCode == http.StatusTeapot ||
+		statusCode >= 500
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryingGetter is the GET loop shared by the exchange clients. The hooks let a client meter
+// requests (Binance weight) without the loop knowing about it.
+type retryingGetter struct {
+	httpClient   *http.Client
+	policy       RetryPolicy
+	exchangeName string
+	header       http.Header
+
+	beforeAttempt func(ctx context.Context) error
+	afterResponse func(header http.Header)
+	onRetryAfter  func(until time.Time)
+
+	// sleep waits between attempts; nil uses sleepContext (tests record the waits instead)
+	sleep func(ctx context.Context, duration time.Duration) error
+}
+
+// get retries 429/418/5xx responses and transport errors with jittered exponential backoff,
+// or for exactly as long as Retry-After says when the server sends it.
+func (getter retryingGetter) get(ctx context.Context, requestURL string) ([]byte, error) {
+	var lastErr error
+
+	for attempt := 0; ; attempt++ {
+		if getter.beforeAttempt != nil {
+			if err := getter.beforeAttempt(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		body, wait, err := getter.getOnce(ctx, requestURL)
+		if err == nil {
+			return body, nil
+		}
+		if wait < 0 {
+			// Not retryable (e.g. 400 bad symbol)
+			return nil, err
+		}
+		lastErr = err
+
+		if attempt >= getter.policy.MaxRetries {
+			break
+		}
+		if wait == 0 {
+			wait = getter.policy.Backoff(attempt)
+		} else if getter.onRetryAfter != nil {
+			getter.onRetryAfter(time.Now().Add(wait))
+		}
+		sleep := getter.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("%s request failed after %d attempts: %w", getter.exchangeName, getter.policy.MaxRetries+1, lastErr)
+}
+
+// getOnce returns wait < 0 for permanent failures, wait > 0 when the server said how long
+// to back off, and wait == 0 for transient failures that should use the retry policy.
+func (getter retryingGetter) getOnce(ctx context.Context, requestURL string) (body []byte, wait time.Duration, err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, -1, err
+	}
+	for key, values := range getter.header {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+
+	response, err := getter.httpClient.Do(request)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, -1, err
+		}
+		return nil, 0, err
+	}
+	defer response.Body.Close()
+
+	if getter.afterResponse != nil {
+		getter.afterResponse(response.Header)
+	}
+
+	body, err = io.ReadAll(response.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s non-200: %s body=%s", getter.exchangeName, response.Status, string(body))
+		if !isRetryableStatus(response.StatusCode) {
+			return nil, -1, err
+		}
+		if retryWait, ok := retryAfter(response.Header, time.Now()); ok {
+			return nil, retryWait, err
+		}
+		return nil, 0, err
+	}
+
+	return body, 0, nil
+}
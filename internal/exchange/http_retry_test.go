@@ -0,0 +1,178 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status (and header) and then answers 200.
+func flakyServer(t *testing.T, failures int, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if int(attempts.Add(1)) <= failures {
+			for key, values := range header {
+				for _, value := range values {
+					writer.Header().Add(key, value)
+				}
+			}
+			writer.WriteHeader(status)
+			_, _ = writer.Write([]byte(`{"code":-1}`))
+			return
+		}
+		_, _ = writer.Write([]byte(`ok`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &attempts
+}
+
+// recordingGetter is a retryingGetter whose sleeps are recorded instead of waited.
+func recordingGetter(policy RetryPolicy) (retryingGetter, *[]time.Duration) {
+	var sleeps []time.Duration
+	getter := retryingGetter{
+		httpClient:   http.DefaultClient,
+		policy:       policy,
+		exchangeName: "test",
+		sleep: func(ctx context.Context, duration time.Duration) error {
+			sleeps = append(sleeps, duration)
+			return nil
+		},
+	}
+	return getter, &sleeps
+}
+
+func TestRetryingGetterRetriesTransientStatuses(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 6, BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusTeapot, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			server, attempts := flakyServer(t, 3, status, nil)
+			getter, sleeps := recordingGetter(policy)
+
+			body, err := getter.get(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if string(body) != "ok" {
+				t.Fatalf("body = %q, want ok", body)
+			}
+			if got := attempts.Load(); got != 4 {
+				t.Fatalf("attempts = %d, want 4", got)
+			}
+			if len(*sleeps) != 3 {
+				t.Fatalf("sleeps = %v, want 3", *sleeps)
+			}
+			// Jittered exponential backoff: retry i waits within [d/2, d], d = 100ms * 2^i
+			for i, sleep := range *sleeps {
+				upper := policy.BaseBackoff << i
+				if sleep < upper/2 || sleep > upper {
+					t.Errorf("sleep %d = %v, want within [%v, %v]", i, sleep, upper/2, upper)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryingGetterHonorsRetryAfter(t *testing.T) {
+	server, attempts := flakyServer(t, 2, http.StatusTooManyRequests, http.Header{"Retry-After": {"7"}})
+	getter, sleeps := recordingGetter(RetryPolicy{MaxRetries: 6, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	var pausedUntil []time.Time
+	getter.onRetryAfter = func(until time.Time) { pausedUntil = append(pausedUntil, until) }
+
+	start := time.Now()
+	if _, err := getter.get(context.Background(), server.URL); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Fatalf("attempts = %d, want 3", got)
+	}
+	if len(*sleeps) != 2 || (*sleeps)[0] != 7*time.Second || (*sleeps)[1] != 7*time.Second {
+		t.Fatalf("sleeps = %v, want [7s 7s]", *sleeps)
+	}
+	if len(pausedUntil) != 2 {
+		t.Fatalf("onRetryAfter called %d times, want 2", len(pausedUntil))
+	}
+	if wait := pausedUntil[0].Sub(start); wait < 7*time.Second || wait > 8*time.Second {
+		t.Errorf("paused for %v, want about 7s", wait)
+	}
+}
+
+func TestRetryingGetterFailsImmediatelyOnClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			server, attempts := flakyServer(t, 100, status, http.Header{"Retry-After": {"1"}})
+			getter, sleeps := recordingGetter(DefaultRetryPolicy())
+
+			_, err := getter.get(context.Background(), server.URL)
+			if err == nil {
+				t.Fatal("get succeeded, want an error")
+			}
+			if got := attempts.Load(); got != 1 {
+				t.Fatalf("attempts = %d, want 1", got)
+			}
+			if len(*sleeps) != 0 {
+				t.Fatalf("slept %v before failing", *sleeps)
+			}
+		})
+	}
+}
+
+func TestRetryingGetterGivesUpAfterMaxRetries(t *testing.T) {
+	server, attempts := flakyServer(t, 100, http.StatusBadGateway, nil)
+	getter, sleeps := recordingGetter(RetryPolicy{MaxRetries: 3, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	_, err := getter.get(context.Background(), server.URL)
+	if err == nil || !strings.Contains(err.Error(), "after 4 attempts") {
+		t.Fatalf("err = %v, want failure after 4 attempts", err)
+	}
+	if got := attempts.Load(); got != 4 {
+		t.Fatalf("attempts = %d, want 4", got)
+	}
+	if len(*sleeps) != 3 {
+		t.Fatalf("sleeps = %v, want 3", *sleeps)
+	}
+}
+
+func TestRetryPolicyBackoffIsCapped(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: time.Second, MaxBackoff: 4 * time.Second}
+	for attempt := 0; attempt < 10; attempt++ {
+		upper := min(policy.BaseBackoff<<attempt, policy.MaxBackoff)
+		for i := 0; i < 50; i++ {
+			if backoff := policy.Backoff(attempt); backoff < upper/2 || backoff > upper {
+				t.Fatalf("Backoff(%d) = %v, want within [%v, %v]", attempt, backoff, upper/2, upper)
+			}
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"30", 30 * time.Second, true},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"-5", 0, false},
+		{"soon", 0, false},
+	}
+	for _, test := range tests {
+		header := http.Header{}
+		if test.value != "" {
+			header.Set("Retry-After", test.value)
+		}
+		got, ok := retryAfter(header, now)
+		if got != test.want || ok != test.ok {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", test.value, got, ok, test.want, test.ok)
+		}
+	}
+}
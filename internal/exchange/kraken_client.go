@@ -0,0 +1,127 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+func init() {
+	RegisterCandleSource("kraken", func(options SourceOptions) CandleSource {
+		client := NewKrakenClient()
+		client.HTTPClient = options.HTTPClient
+		if options.BaseURL != "" {
+			client.BaseURL = options.BaseURL
+		}
+		return client
+	})
+}
+
+var krakenIntervalMinutes = map[string]int64{
+	"1m":  1,
+	"5m":  5,
+	"15m": 15,
+	"30m": 30,
+	"1h":  60,
+	"4h":  240,
+	"1d":  1440,
+}
+
+var krakenTimeframes = []string{"1m", "5m", "15m", "30m", "1h", "4h", "1d"}
+
+type KrakenClient struct {
+	HTTPClient *http.Client
+	BaseURL    string
+
+	Retry RetryPolicy
+}
+
+func NewKrakenClient() KrakenClient {
+	return KrakenClient{HTTPClient: http.DefaultClient, BaseURL: krakenBaseURL, Retry: DefaultRetryPolicy()}
+}
+
+func (client KrakenClient) Get(ctx context.Context, requestURL string) ([]byte, error) {
+	getter := retryingGetter{
+		httpClient:   client.HTTPClient,
+		policy:       client.Retry,
+		exchangeName: "kraken",
+	}
+	return getter.get(ctx, requestURL)
+}
+
+func (client KrakenClient) Name() string {
+	return "kraken"
+}
+
+func (client KrakenClient) Timeframes() []string {
+	return krakenTimeframes
+}
+
+// ReportsOrderFlow is false: OHLC rows carry a trade count but no quote or taker-buy volume.
+func (client KrakenClient) ReportsOrderFlow() bool {
+	return false
+}
+
+// FetchCandles follows Kraken's "last" cursor from startTime. Kraken only serves the most
+// recent 720 bars of any interval, so older history is simply not returned.
+// endTimeMillis <= 0 means "up to now".
+func (client KrakenClient) FetchCandles(
+	ctx context.Context,
+	symbol string,
+	timeframe string,
+	startTimeMillis int64,
+	endTimeMillis int64,
+) ([]candles.Candle, error) {
+	intervalMinutes, ok := krakenIntervalMinutes[timeframe]
+	if !ok {
+		return nil, fmt.Errorf("%s does not support timeframe %q", client.Name(), timeframe)
+	}
+	intervalMillis := intervalMinutes * 60 * 1000
+
+	nowMillis := time.Now().UnixMilli()
+	// since is exclusive; step back one second so a bar opening exactly at startTime is included
+	sinceSeconds := startTimeMillis/1000 - 1
+
+	var allCandles []candles.Candle
+
+	for {
+		body, err := client.Get(ctx, BuildKrakenOHLCURL(client.BaseURL, symbol, intervalMinutes, sinceSeconds))
+		if err != nil {
+			return nil, err
+		}
+
+		pageCandles, last, err := ParseKrakenOHLC(body, client.Name(), symbol, timeframe, intervalMillis, nowMillis)
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for _, candle := range pageCandles {
+			if candle.Timestamp < startTimeMillis {
+				continue
+			}
+			if endTimeMillis > 0 && candle.Timestamp > endTimeMillis {
+				continue
+			}
+			if len(allCandles) > 0 && candle.Timestamp <= allCandles[len(allCandles)-1].Timestamp {
+				continue
+			}
+			allCandles = append(allCandles, candle)
+			added++
+		}
+
+		// Stop when the cursor stops moving or nothing new arrived
+		if added == 0 || last <= sinceSeconds {
+			break
+		}
+		if endTimeMillis > 0 && last*1000 > endTimeMillis {
+			break
+		}
+		sinceSeconds = last
+	}
+
+	return allCandles, nil
+}
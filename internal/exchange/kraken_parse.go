@@ -0,0 +1,102 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+type krakenOHLCResponse struct {
+	Error  []string                   `json:"error"`
+	Result map[string]json.RawMessage `json:"result"`
+}
+
+// ParseKrakenOHLC converts a Kraken OHLC response into candles and returns the "last" cursor
+// (unix seconds) to use as the next since. Kraken rows are
+// [time(s), "open", "high", "low", "close", "vwap", "volume", count]. The result is keyed by
+// Kraken's internal pair name (XBTUSD -> XXBTZUSD), so the single non-"last" key is used.
+func ParseKrakenOHLC(body []byte, exchangeName, symbol, timeframe string, intervalMillis int64, nowMillis int64) ([]candles.Candle, int64, error) {
+	var response krakenOHLCResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, 0, err
+	}
+	if len(response.Error) > 0 {
+		return nil, 0, fmt.Errorf("kraken error: %s", strings.Join(response.Error, "; "))
+	}
+
+	var last int64
+	var rows [][]interface{}
+	for key, raw := range response.Result {
+		if key == "last" {
+			if err := json.Unmarshal(raw, &last); err != nil {
+				return nil, 0, fmt.Errorf("kraken last cursor: %w", err)
+			}
+			continue
+		}
+		if err := json.Unmarshal(raw, &rows); err != nil {
+			return nil, 0, fmt.Errorf("kraken ohlc rows for %s: %w", key, err)
+		}
+	}
+
+	result := make([]candles.Candle, 0, len(rows))
+	for _, r := range rows {
+		if len(r) < 8 {
+			return nil, 0, fmt.Errorf("unexpected kraken ohlc length: %d", len(r))
+		}
+
+		openTimeSeconds, ok := r[0].(float64)
+		if !ok {
+			return nil, 0, fmt.Errorf("kraken ohlc time is not a number: %v", r[0])
+		}
+		values := make([]float64, 4)
+		for i, column := range []int{1, 2, 3, 4} {
+			text, ok := r[column].(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("kraken ohlc column %d is not a string: %v", column, r[column])
+			}
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, 0, err
+			}
+			values[i] = value
+		}
+		volumeText, ok := r[6].(string)
+		if !ok {
+			return nil, 0, fmt.Errorf("kraken ohlc volume is not a string: %v", r[6])
+		}
+		volume, err := strconv.ParseFloat(volumeText, 64)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		openTime := int64(openTimeSeconds) * 1000
+		closeTime := openTime + intervalMillis - 1
+
+		// Kraken reports a trade count but no quote or taker volumes
+		var trades *int64
+		if count, ok := r[7].(float64); ok {
+			tradeCount := int64(count)
+			trades = &tradeCount
+		}
+
+		result = append(result, candles.Candle{
+			Exchange:  exchangeName,
+			Symbol:    symbol,
+			Timeframe: timeframe,
+			Timestamp: openTime,
+			Open:      values[0],
+			High:      values[1],
+			Low:       values[2],
+			Close:     values[3],
+			Volume:    volume,
+			CloseTime: closeTime,
+			IsFinal:   closeTime < nowMillis,
+			Trades:    trades,
+		})
+	}
+
+	return result, last, nil
+}
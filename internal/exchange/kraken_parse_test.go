@@ -0,0 +1,65 @@
+package exchange
+
+import (
+	"strings"
+	"testing"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+func TestParseKrakenOHLC(t *testing.T) {
+	const fourHours = 4 * 60 * 60 * 1000
+	// The newest bar (opened 2024-03-01 08:00 UTC) is still forming at this time
+	now := int64(1709290000000)
+
+	got, last, err := ParseKrakenOHLC(readFixture(t, "kraken_ohlc_xbtusd_4h.json"), "kraken", "XBTUSD", "4h", fourHours, now)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if last != 1709265600 {
+		t.Errorf("last cursor = %d, want 1709265600 (unix seconds)", last)
+	}
+
+	trades := func(count int64) *int64 { return &count }
+	// Rows are [time, "open", "high", "low", "close", "vwap", "volume", count] with string prices
+	want := []candles.Candle{
+		{Timestamp: 1709251200000, Open: 61130.9, High: 61600, Low: 61000.1, Close: 61500, Volume: 152.12345678, CloseTime: 1709265599999, IsFinal: true, Trades: trades(4821)},
+		{Timestamp: 1709265600000, Open: 61500, High: 61950.5, Low: 60900, Close: 61820.1, Volume: 98.765, CloseTime: 1709279999999, IsFinal: true, Trades: trades(3120)},
+		{Timestamp: 1709280000000, Open: 61820.1, High: 62450, Low: 61100.5, Close: 62001.9, Volume: 123.456789, CloseTime: 1709294399999, IsFinal: false, Trades: trades(4002)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d candles, want %d", len(got), len(want))
+	}
+	for i := range want {
+		want[i].Exchange, want[i].Symbol, want[i].Timeframe = "kraken", "XBTUSD", "4h"
+		if got[i].Trades == nil || *got[i].Trades != *want[i].Trades {
+			t.Errorf("candle %d trades = %v, want %d", i, got[i].Trades, *want[i].Trades)
+		}
+		got[i].Trades, want[i].Trades = nil, nil
+		if got[i] != want[i] {
+			t.Errorf("candle %d:\n got  %+v\n want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseKrakenOHLCErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"api error", string(readFixture(t, "kraken_ohlc_error.json")), "kraken error: EQuery:Unknown asset pair"},
+		{"short row", `{"error":[],"result":{"XXBTZUSD":[[1709251200,"1","2","0.5","1.5","1.2","10"]],"last":0}}`, "unexpected kraken ohlc length: 7"},
+		{"numeric price", `{"error":[],"result":{"XXBTZUSD":[[1709251200,1,"2","0.5","1.5","1.2","10",3]],"last":0}}`, "column 1 is not a string"},
+		{"bad price", `{"error":[],"result":{"XXBTZUSD":[[1709251200,"x","2","0.5","1.5","1.2","10",3]],"last":0}}`, "invalid syntax"},
+		{"string cursor", `{"error":[],"result":{"XXBTZUSD":[],"last":"1709251200"}}`, "kraken last cursor"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := ParseKrakenOHLC([]byte(test.body), "kraken", "XBTUSD", "4h", 4*60*60*1000, 0)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("err = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package exchange
+
+import (
+	"net/url"
+	"strconv"
+)
+
+const krakenBaseURL = "https://api.kraken.com"
+
+// BuildKrakenOHLCURL builds a Kraken OHLC request; since is in unix seconds (0 = omit).
+func BuildKrakenOHLCURL(baseURL string, pair string, intervalMinutes int64, sinceSeconds int64) string {
+	query := url.Values{}
+	query.Set("pair", pair)
+	query.Set("interval", strconv.FormatInt(intervalMinutes, 10))
+	if sinceSeconds > 0 {
+		query.Set("since", strconv.FormatInt(sinceSeconds, 10))
+	}
+
+	return baseURL + "/0/public/OHLC?" + query.Encode()
+}
@@ -0,0 +1,87 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+// CandleSource is an exchange that can serve historical candles for a symbol/timeframe.
+// Name is also the value stored in candles.exchange for everything it returns.
+type CandleSource interface {
+	Name() string
+	Timeframes() []string
+	// ReportsOrderFlow is whether fetched candles carry quote volume, trade count and taker-buy
+	// volumes; sources without it leave those columns NULL for good.
+	ReportsOrderFlow() bool
+	FetchCandles(ctx context.Context, symbol string, timeframe string, startTimeMillis int64, endTimeMillis int64) ([]candles.Candle, error)
+}
+
+type SourceOptions struct {
+	HTTPClient *http.Client
+
+	// WeightBudget is the per-minute request weight for exchanges that meter by weight
+	// (0 = exchange default, negative disables client-side limiting).
+	WeightBudget int
+
+	// BaseURL overrides the exchange's API root, e.g. to point at a local mock server.
+	BaseURL string
+}
+
+type CandleSourceFactory func(options SourceOptions) CandleSource
+
+var (
+	candleSourcesMutex sync.RWMutex
+	candleSources      = map[string]CandleSourceFactory{}
+)
+
+// RegisterCandleSource makes a source available to NewCandleSource under name.
+func RegisterCandleSource(name string, factory CandleSourceFactory) {
+	candleSourcesMutex.Lock()
+	defer candleSourcesMutex.Unlock()
+
+	if _, exists := candleSources[name]; exists {
+		panic(fmt.Sprintf("candle source %q registered twice", name))
+	}
+	candleSources[name] = factory
+}
+
+func NewCandleSource(name string, options SourceOptions) (CandleSource, error) {
+	candleSourcesMutex.RLock()
+	factory, ok := candleSources[name]
+	candleSourcesMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown exchange %q (available: %s)", name, strings.Join(CandleSourceNames(), ", "))
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	return factory(options), nil
+}
+
+func CandleSourceNames() []string {
+	candleSourcesMutex.RLock()
+	defer candleSourcesMutex.RUnlock()
+
+	names := make([]string, 0, len(candleSources))
+	for name := range candleSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func SupportsTimeframe(source CandleSource, timeframe string) bool {
+	for _, supported := range source.Timeframes() {
+		if supported == timeframe {
+			return true
+		}
+	}
+	return false
+}
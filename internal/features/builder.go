@@ -8,90 +8,104 @@ import (
 )
 
 func BuildFeaturesFromCandles(candleSeries []candles.Candle) ([]FeatureRow, error) {
+	// Never build on a still-forming bar
+	candleSeries = candles.FinalOnly(candleSeries)
+
 	if len(candleSeries) < 2 {
 		return nil, fmt.Errorf("need at least 2 candles")
 	}
 
-	// Returns array aligned to candles (returns[0] is NaN)
-	returns := make([]float64, len(candleSeries))
-	returns[0] = math.NaN()
-	for i := 1; i < len(candleSeries); i++ {
-		returns[i] = LogReturn(candleSeries[i].Close, candleSeries[i-1].Close)
+	builder := NewBuilder()
+	rows := make([]FeatureRow, 0, len(candleSeries))
+	for _, c := range candleSeries {
+		rows = append(rows, builder.Update(c))
 	}
+	return rows, nil
+}
 
-	alpha10 := AlphaFromPeriod(10)
-	alpha30 := AlphaFromPeriod(30)
+// Builder computes the fixed feature columns one final candle at a time. Its state is exported
+// and round-trips exactly through JSON, so an incremental run resumes where the previous run
+// stopped and produces the same rows as a full rebuild.
+type Builder struct {
+	Count          int     `json:"count"` // candles consumed
+	PreviousClose  float64 `json:"previous_close"`
+	PreviousVolume float64 `json:"previous_volume"`
 
-	var ema10 float64
-	var ema30 float64
-	emaInitialized := false
+	// Last 20 1-bar log returns (vol_20; mom_6 uses the last 6)
+	Returns rollingWindow `json:"returns"`
 
-	var rows []FeatureRow
+	Ema10 float64 `json:"ema_10"`
+	Ema30 float64 `json:"ema_30"`
+}
 
-	for i := 0; i < len(candleSeries); i++ {
-		c := candleSeries[i]
+func NewBuilder() *Builder {
+	return &Builder{Returns: newRollingWindow(20)}
+}
 
-		row := FeatureRow{
-			Exchange:  c.Exchange,
-			Symbol:    c.Symbol,
-			Timeframe: c.Timeframe,
-			Timestamp: c.Timestamp,
-		}
+// Update consumes the next final candle of the series and returns its feature row (funding and
+// open interest are joined separately by JoinDerivatives).
+func (builder *Builder) Update(c candles.Candle) FeatureRow {
+	row := FeatureRow{
+		Exchange:  c.Exchange,
+		Symbol:    c.Symbol,
+		Timeframe: c.Timeframe,
+		Timestamp: c.Timestamp,
+	}
 
-		// Range features: available immediately
-		rangeHL := (c.High - c.Low) / c.Close
-		rangeCO := (c.Close - c.Open) / c.Open
-		row.RangeHL = &rangeHL
-		row.RangeCO = &rangeCO
+	// Range features: available immediately
+	rangeHL := (c.High - c.Low) / c.Close
+	rangeCO := (c.Close - c.Open) / c.Open
+	row.RangeHL = &rangeHL
+	row.RangeCO = &rangeCO
 
+	if builder.Count >= 1 {
 		// Volume change: needs previous
-		if i >= 1 && candleSeries[i-1].Volume > 0 && c.Volume > 0 {
-			volChg := math.Log(c.Volume / candleSeries[i-1].Volume)
+		if builder.PreviousVolume > 0 && c.Volume > 0 {
+			volChg := math.Log(c.Volume / builder.PreviousVolume)
 			row.VolChg = &volChg
 		}
 
 		// Return (1-bar): needs previous
-		if i >= 1 {
-			ret1 := returns[i]
-			row.Ret1 = &ret1
-		}
-
-		// Momentum over 6 bars: sum last 6 returns (i-5..i)
-		if i >= 6 {
-			var sum float64
-			for k := i - 5; k <= i; k++ {
-				sum += returns[k]
-			}
-			mom6 := sum
-			row.Mom6 = &mom6
-		}
+		ret1 := LogReturn(c.Close, builder.PreviousClose)
+		row.Ret1 = &ret1
+		builder.Returns.Push(ret1)
+	}
 
-		// Volatility over 20 bars
-		if i >= 20 {
-			vol20 := RollingStd(returns, i, 20)
-			row.Vol20 = &vol20
+	// Momentum over 6 bars: sum of the last 6 returns
+	if builder.Count >= 6 {
+		var sum float64
+		for _, r := range builder.Returns.Values[len(builder.Returns.Values)-6:] {
+			sum += r
 		}
+		mom6 := sum
+		row.Mom6 = &mom6
+	}
 
-		// EMA features: initialize on first close
-		if !emaInitialized {
-			ema10 = c.Close
-			ema30 = c.Close
-			emaInitialized = true
-		} else {
-			ema10 = EMA(ema10, c.Close, alpha10)
-			ema30 = EMA(ema30, c.Close, alpha30)
-		}
+	// Volatility over 20 bars
+	if builder.Count >= 20 {
+		vol20 := builder.Returns.Std()
+		row.Vol20 = &vol20
+	}
 
-		ema10Copy := ema10
-		ema30Copy := ema30
-		row.Ema10 = &ema10Copy
-		row.Ema30 = &ema30Copy
+	// EMA features: initialize on first close
+	if builder.Count == 0 {
+		builder.Ema10 = c.Close
+		builder.Ema30 = c.Close
+	} else {
+		builder.Ema10 = EMA(builder.Ema10, c.Close, AlphaFromPeriod(10))
+		builder.Ema30 = EMA(builder.Ema30, c.Close, AlphaFromPeriod(30))
+	}
 
-		emaSpread := ema10 - ema30
-		row.EmaSpread = &emaSpread
+	ema10 := builder.Ema10
+	ema30 := builder.Ema30
+	row.Ema10 = &ema10
+	row.Ema30 = &ema30
 
-		rows = append(rows, row)
-	}
+	emaSpread := ema10 - ema30
+	row.EmaSpread = &emaSpread
 
-	return rows, nil
+	builder.Count++
+	builder.PreviousClose = c.Close
+	builder.PreviousVolume = c.Volume
+	return row
 }
@@ -0,0 +1,274 @@
+package features
+
+import (
+	"math"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+// Built-in registry calculators. They mirror the fixed feature columns but take their window
+// lengths from the spec, e.g. ema(20), mom(12), vol(50).
+func init() {
+	Register(Definition{
+		Name:        "ema",
+		Params:      []string{"period"},
+		Description: "Exponential moving average of close, seeded with the first close",
+		New: func(params []float64) (Calculator, error) {
+			period, err := periodParam("period", params[0], 1)
+			if err != nil {
+				return nil, err
+			}
+			return newEMACalculator(period), nil
+		},
+	})
+	Register(Definition{
+		Name:        "ema_spread",
+		Params:      []string{"fast", "slow"},
+		Defaults:    []float64{10, 30},
+		Description: "ema(fast) - ema(slow)",
+		New: func(params []float64) (Calculator, error) {
+			fast, err := periodParam("fast", params[0], 1)
+			if err != nil {
+				return nil, err
+			}
+			slow, err := periodParam("slow", params[1], 1)
+			if err != nil {
+				return nil, err
+			}
+			return &emaSpreadCalculator{Fast: newEMACalculator(fast), Slow: newEMACalculator(slow)}, nil
+		},
+	})
+	Register(Definition{
+		Name:        "sma",
+		Params:      []string{"period"},
+		Description: "Simple moving average of close",
+		New: func(params []float64) (Calculator, error) {
+			period, err := periodParam("period", params[0], 1)
+			if err != nil {
+				return nil, err
+			}
+			return &smaCalculator{Closes: newRollingWindow(period)}, nil
+		},
+	})
+	Register(Definition{
+		Name:        "mom",
+		Params:      []string{"period"},
+		Description: "Log return over the last period bars",
+		New: func(params []float64) (Calculator, error) {
+			period, err := periodParam("period", params[0], 1)
+			if err != nil {
+				return nil, err
+			}
+			return &momCalculator{Closes: newRollingWindow(period + 1)}, nil
+		},
+	})
+	Register(Definition{
+		Name:        "vol",
+		Params:      []string{"period"},
+		Description: "Population standard deviation of the last period 1-bar log returns",
+		New: func(params []float64) (Calculator, error) {
+			period, err := periodParam("period", params[0], 2)
+			if err != nil {
+				return nil, err
+			}
+			return &volCalculator{Returns: newRollingWindow(period)}, nil
+		},
+	})
+	Register(Definition{
+		Name:        "range_hl",
+		Description: "(high - low) / close",
+		New: func(params []float64) (Calculator, error) {
+			return &rangeHLCalculator{}, nil
+		},
+	})
+	Register(Definition{
+		Name:        "range_co",
+		Description: "(close - open) / open",
+		New: func(params []float64) (Calculator, error) {
+			return &rangeCOCalculator{}, nil
+		},
+	})
+	Register(Definition{
+		Name:        "vol_chg",
+		Description: "Log change in volume from the previous bar",
+		New: func(params []float64) (Calculator, error) {
+			return &volumeChangeCalculator{}, nil
+		},
+	})
+}
+
+// rollingWindow keeps the last Size values, oldest first.
+type rollingWindow struct {
+	Size   int       `json:"size"`
+	Values []float64 `json:"values"`
+}
+
+func newRollingWindow(size int) rollingWindow {
+	return rollingWindow{Size: size, Values: make([]float64, 0, size)}
+}
+
+func (window *rollingWindow) Push(value float64) {
+	window.Values = append(window.Values, value)
+	if len(window.Values) > window.Size {
+		window.Values = window.Values[1:]
+	}
+}
+
+func (window rollingWindow) Full() bool {
+	return len(window.Values) == window.Size
+}
+
+func (window rollingWindow) Mean() float64 {
+	var sum float64
+	for _, value := range window.Values {
+		sum += value
+	}
+	return sum / float64(len(window.Values))
+}
+
+// SampleVariance divides by n-1; callers need at least two values.
+func (window rollingWindow) SampleVariance() float64 {
+	mean := window.Mean()
+	var sum float64
+	for _, value := range window.Values {
+		diff := value - mean
+		sum += diff * diff
+	}
+	return sum / float64(len(window.Values)-1)
+}
+
+// Std is the population standard deviation, as in RollingStd.
+func (window rollingWindow) Std() float64 {
+	return RollingStd(window.Values, len(window.Values)-1, len(window.Values))
+}
+
+// closeChange tracks the previous close for calculators built on close-to-close changes.
+type closeChange struct {
+	PreviousClose float64 `json:"previous_close"`
+	HasPrevious   bool    `json:"has_previous"`
+}
+
+// Update returns the log return into this close; ok is false on the first bar.
+func (change *closeChange) Update(close float64) (float64, bool) {
+	previous, ok := change.advance(close)
+	if !ok {
+		return 0, false
+	}
+	return LogReturn(close, previous), true
+}
+
+// Difference returns close minus the previous close; ok is false on the first bar.
+func (change *closeChange) Difference(close float64) (float64, bool) {
+	previous, ok := change.advance(close)
+	if !ok {
+		return 0, false
+	}
+	return close - previous, true
+}
+
+func (change *closeChange) advance(close float64) (float64, bool) {
+	previous, ok := change.PreviousClose, change.HasPrevious
+	change.PreviousClose, change.HasPrevious = close, true
+	return previous, ok
+}
+
+type emaCalculator struct {
+	Period int     `json:"period"`
+	Alpha  float64 `json:"alpha"`
+	Value  float64 `json:"value"`
+	Count  int     `json:"count"`
+}
+
+func newEMACalculator(period int) *emaCalculator {
+	return &emaCalculator{Period: period, Alpha: AlphaFromPeriod(period)}
+}
+
+// Update seeds on the first close like the fixed ema_10/ema_30 columns, but only reports once
+// period closes have been seen.
+func (calculator *emaCalculator) Update(candle candles.Candle) (float64, bool) {
+	if calculator.Count == 0 {
+		calculator.Value = candle.Close
+	} else {
+		calculator.Value = EMA(calculator.Value, candle.Close, calculator.Alpha)
+	}
+	calculator.Count++
+	return calculator.Value, calculator.Count >= calculator.Period
+}
+
+type emaSpreadCalculator struct {
+	Fast *emaCalculator `json:"fast"`
+	Slow *emaCalculator `json:"slow"`
+}
+
+func (calculator *emaSpreadCalculator) Update(candle candles.Candle) (float64, bool) {
+	fast, fastOK := calculator.Fast.Update(candle)
+	slow, slowOK := calculator.Slow.Update(candle)
+	return fast - slow, fastOK && slowOK
+}
+
+type smaCalculator struct {
+	Closes rollingWindow `json:"closes"`
+}
+
+func (calculator *smaCalculator) Update(candle candles.Candle) (float64, bool) {
+	calculator.Closes.Push(candle.Close)
+	if !calculator.Closes.Full() {
+		return 0, false
+	}
+	return calculator.Closes.Mean(), true
+}
+
+type momCalculator struct {
+	Closes rollingWindow `json:"closes"` // period+1 closes
+}
+
+func (calculator *momCalculator) Update(candle candles.Candle) (float64, bool) {
+	calculator.Closes.Push(candle.Close)
+	if !calculator.Closes.Full() {
+		return 0, false
+	}
+	return LogReturn(candle.Close, calculator.Closes.Values[0]), true
+}
+
+type volCalculator struct {
+	Change  closeChange   `json:"change"`
+	Returns rollingWindow `json:"returns"`
+}
+
+func (calculator *volCalculator) Update(candle candles.Candle) (float64, bool) {
+	logReturn, ok := calculator.Change.Update(candle.Close)
+	if !ok {
+		return 0, false
+	}
+	calculator.Returns.Push(logReturn)
+	if !calculator.Returns.Full() {
+		return 0, false
+	}
+	return calculator.Returns.Std(), true
+}
+
+type rangeHLCalculator struct{}
+
+func (rangeHLCalculator) Update(candle candles.Candle) (float64, bool) {
+	return (candle.High - candle.Low) / candle.Close, true
+}
+
+type rangeCOCalculator struct{}
+
+func (rangeCOCalculator) Update(candle candles.Candle) (float64, bool) {
+	return (candle.Close - candle.Open) / candle.Open, true
+}
+
+type volumeChangeCalculator struct {
+	PreviousVolume float64 `json:"previous_volume"`
+	HasPrevious    bool    `json:"has_previous"`
+}
+
+func (calculator *volumeChangeCalculator) Update(candle candles.Candle) (float64, bool) {
+	previous, ok := calculator.PreviousVolume, calculator.HasPrevious
+	calculator.PreviousVolume, calculator.HasPrevious = candle.Volume, true
+	if !ok || previous <= 0 || candle.Volume <= 0 {
+		return 0, false
+	}
+	return math.Log(candle.Volume / previous), true
+}
@@ -0,0 +1,57 @@
+package features
+
+import (
+	"math"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/derivatives"
+)
+
+// Funding settles every 8h (4h on some contracts); a value older than a day is a data hole.
+const fundingRateMaxAgeMillis = 24 * 60 * 60 * 1000
+
+// JoinDerivatives sets FundingRate and OIChg from the last funding settlement and open-interest
+// sample at or before each bar's open time, so a bar never sees values published after it
+// opened. OIChg is the log change in open interest since the previous row's sample. Rows must be
+// in time order; rows without a recent enough value keep nil.
+func JoinDerivatives(rows []FeatureRow, fundingRates []derivatives.FundingRate, openInterest []derivatives.OpenInterest) error {
+	barOpenTimes := make([]int64, len(rows))
+	for i, row := range rows {
+		barOpenTimes[i] = row.Timestamp
+	}
+
+	fundingTimes := make([]int64, len(fundingRates))
+	for i, rate := range fundingRates {
+		fundingTimes[i] = rate.FundingTime
+	}
+	for i, index := range derivatives.AsOfIndexes(barOpenTimes, fundingTimes, fundingRateMaxAgeMillis) {
+		if index >= 0 {
+			rate := fundingRates[index].Rate
+			rows[i].FundingRate = &rate
+		}
+	}
+
+	if len(openInterest) == 0 {
+		return nil
+	}
+	period, err := candles.ParseTimeframe(openInterest[0].Period)
+	if err != nil {
+		return err
+	}
+	sampleTimes := make([]int64, len(openInterest))
+	for i, sample := range openInterest {
+		sampleTimes[i] = sample.Timestamp
+	}
+	indexes := derivatives.AsOfIndexes(barOpenTimes, sampleTimes, 2*period.NominalMillis())
+	for i := 1; i < len(rows); i++ {
+		current, previous := indexes[i], indexes[i-1]
+		if current < 0 || previous < 0 {
+			continue
+		}
+		if openInterest[current].SumOpenInterest > 0 && openInterest[previous].SumOpenInterest > 0 {
+			oiChg := math.Log(openInterest[current].SumOpenInterest / openInterest[previous].SumOpenInterest)
+			rows[i].OIChg = &oiChg
+		}
+	}
+	return nil
+}
@@ -0,0 +1,551 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/derivatives"
+)
+
+// Incremental features: every stream (the fixed feature columns and each registry spec) saves
+// its streaming state after a run, so the next run only feeds it the candles that arrived
+// since. A stream starts over when there is no state, or when a series it reads no longer holds
+// exactly the candles it consumed (backfilled gaps, re-ingested history).
+
+// fixedFeaturesStream is the StreamState name of the fixed feature columns.
+const fixedFeaturesStream = "features"
+
+// StreamState is the saved progress of one feature stream over one series (a feature_state row).
+type StreamState struct {
+	Exchange  string
+	Symbol    string
+	Timeframe string
+	Name      string // Spec.StoredName, or "features" for the fixed columns
+	Params    string // Spec.ParamsString
+
+	LastTimestamp int64 // open time of the last candle consumed from the source series
+	Candles       int   // candles consumed up to LastTimestamp
+	State         string
+	UpdatedAt     int64 // unix seconds
+}
+
+// History reads the final candles of one exchange and symbol, on any timeframe.
+type History interface {
+	// CandlesSince returns the final candles with open time >= from, in time order.
+	CandlesSince(ctx context.Context, timeframe string, from int64) ([]candles.Candle, error)
+	// CountCandlesThrough counts the final candles with open time <= through.
+	CountCandlesThrough(ctx context.Context, timeframe string, through int64) (int, error)
+	// LatestCandleBefore returns the open time of the last final candle opened before before.
+	LatestCandleBefore(ctx context.Context, timeframe string, before int64) (int64, bool, error)
+}
+
+// IncrementalRun is one features run over a series.
+type IncrementalRun struct {
+	Exchange  string
+	Symbol    string
+	Timeframe string
+	Specs     []Spec
+	Saved     []StreamState // states of the previous run; empty starts every stream over
+
+	// Funding and open interest history joined onto the fixed columns
+	FuturesExchange    string
+	FuturesSymbol      string
+	OpenInterestPeriod string
+	FundingRates       []derivatives.FundingRate
+	OpenInterest       []derivatives.OpenInterest
+}
+
+// IncrementalResult is what a run computed. States are saved only once the rows and values are
+// stored, so an interrupted run repeats its work instead of skipping it.
+type IncrementalResult struct {
+	Rows     []FeatureRow   // new fixed-column rows, funding and open interest joined
+	Rejoined []FeatureRow   // earlier rows whose funding/oi_chg join changed
+	Values   []FeatureValue // registry feature values
+	States   []StreamState  // UpdatedAt is left to the caller
+
+	// Streams that started over rebuild everything they stored for the series, and the rebuild
+	// may no longer fill every bar (removed candles, a source bar now too old to join). The
+	// caller deletes the stored rows (RowsRestarted) and the stored values of each
+	// ValuesRestarted spec before storing the new ones.
+	RowsRestarted   bool
+	ValuesRestarted []Spec
+
+	Streams int // fixed columns + registry specs
+	Resumed int // streams that continued from their saved state
+}
+
+// RunIncremental resumes every stream of the series from its saved state (or starts it over)
+// and returns the rows, values and states that bring the stored features up to date. Its
+// output matches a run with no saved states, which rebuilds every row.
+func RunIncremental(ctx context.Context, history History, run IncrementalRun) (IncrementalResult, error) {
+	timeframe, err := candles.ParseTimeframe(run.Timeframe)
+	if err != nil {
+		return IncrementalResult{}, err
+	}
+	run.Timeframe = timeframe.String()
+	states := streamStates{}
+	for _, state := range run.Saved {
+		states[streamStateKey(state.Name, state.Params)] = state
+	}
+	joinState := newDerivativesJoinState(run.FuturesExchange, run.FuturesSymbol, run.OpenInterestPeriod, run.FundingRates, run.OpenInterest)
+
+	// Fixed feature columns: resume the builder after the last stored row
+	fixedSaved, fixedResumed, err := states.resume(ctx, history, fixedFeaturesStream, "", run.Timeframe)
+	if err != nil {
+		return IncrementalResult{}, err
+	}
+	fixed := fixedFeaturesState{Builder: NewBuilder()}
+	var fixedFrom, joinFrom int64
+	if fixedResumed {
+		if err := json.Unmarshal([]byte(fixedSaved.State), &fixed); err != nil {
+			return IncrementalResult{}, fmt.Errorf("restore feature builder state: %w (rerun with --full)", err)
+		}
+		fixedFrom = fixedSaved.LastTimestamp + 1
+		// Funding/open interest that arrived since may change rows built earlier
+		joinFrom = min(fixedFrom, fixed.Derivatives.rejoinFrom(joinState, timeframe, run.FundingRates, run.OpenInterest))
+	}
+
+	// Registry specs, including cross-timeframe ones (e.g. 1d:ema(20)) over their source series
+	result := IncrementalResult{Streams: 1 + len(run.Specs), RowsRestarted: !fixedResumed}
+	if fixedResumed {
+		result.Resumed++
+	}
+	streams := make([]*registryStream, 0, len(run.Specs))
+	from := joinFrom
+	for _, spec := range run.Specs {
+		stream, err := newRegistryStream(ctx, history, run, states, spec)
+		if err != nil {
+			return IncrementalResult{}, err
+		}
+		if stream.resumed {
+			result.Resumed++
+		} else {
+			result.ValuesRestarted = append(result.ValuesRestarted, stream.spec)
+		}
+		from = min(from, stream.from())
+		if stream.spec.Timeframe != "" {
+			from = min(from, stream.joinFrom)
+		}
+		streams = append(streams, stream)
+	}
+
+	candleSeries, err := history.CandlesSince(ctx, run.Timeframe, from)
+	if err != nil {
+		return IncrementalResult{}, err
+	}
+	if !fixedResumed {
+		if len(candleSeries) == 0 {
+			return IncrementalResult{}, fmt.Errorf("no candles found for %s %s", run.Symbol, run.Timeframe)
+		}
+		if len(candleSeries) < 2 {
+			return IncrementalResult{}, fmt.Errorf("need at least 2 candles")
+		}
+	}
+
+	newCandles := candlesSince(candleSeries, fixedFrom)
+	result.Rows = make([]FeatureRow, 0, len(newCandles))
+	for _, candle := range newCandles {
+		result.Rows = append(result.Rows, fixed.Builder.Update(candle))
+	}
+	result.Rejoined, err = joinDerivativesSince(ctx, history, run, candlesSince(candleSeries, joinFrom), result.Rows)
+	if err != nil {
+		return IncrementalResult{}, err
+	}
+
+	for _, stream := range streams {
+		values, err := stream.run(candleSeries)
+		if err != nil {
+			return IncrementalResult{}, err
+		}
+		result.Values = append(result.Values, values...)
+	}
+
+	fixed.Derivatives = joinState
+	fixedState, err := json.Marshal(fixed)
+	if err != nil {
+		return IncrementalResult{}, err
+	}
+	result.States = []StreamState{{
+		Exchange:      run.Exchange,
+		Symbol:        run.Symbol,
+		Timeframe:     run.Timeframe,
+		Name:          fixedFeaturesStream,
+		LastTimestamp: fixedSaved.LastTimestamp,
+		Candles:       fixed.Builder.Count,
+		State:         string(fixedState),
+	}}
+	if len(newCandles) > 0 {
+		result.States[0].LastTimestamp = newCandles[len(newCandles)-1].Timestamp
+	}
+	for _, stream := range streams {
+		state, ok, err := stream.savedState(run)
+		if err != nil {
+			return IncrementalResult{}, err
+		}
+		if ok {
+			result.States = append(result.States, state)
+		}
+	}
+	return result, nil
+}
+
+// joinDerivativesSince joins funding and open interest onto the bars of joinSeries (the tail of
+// the series from the first bar whose join may have changed). New rows get their values in
+// place; the re-joined rows built by earlier runs are returned.
+func joinDerivativesSince(ctx context.Context, history History, run IncrementalRun, joinSeries []candles.Candle, newRows []FeatureRow) ([]FeatureRow, error) {
+	if len(joinSeries) == 0 {
+		return nil, nil
+	}
+
+	// oi_chg compares with the bar before, so start the join one stored bar earlier
+	joinRows := make([]FeatureRow, 0, len(joinSeries)+1)
+	previous, found, err := history.LatestCandleBefore(ctx, run.Timeframe, joinSeries[0].Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		joinRows = append(joinRows, FeatureRow{Timestamp: previous})
+	}
+	for _, candle := range joinSeries {
+		joinRows = append(joinRows, FeatureRow{
+			Exchange:  candle.Exchange,
+			Symbol:    candle.Symbol,
+			Timeframe: candle.Timeframe,
+			Timestamp: candle.Timestamp,
+		})
+	}
+	if err := JoinDerivatives(joinRows, run.FundingRates, run.OpenInterest); err != nil {
+		return nil, err
+	}
+	if found {
+		joinRows = joinRows[1:]
+	}
+
+	newIndexes := make(map[int64]int, len(newRows))
+	for i, row := range newRows {
+		newIndexes[row.Timestamp] = i
+	}
+	var rejoined []FeatureRow
+	for _, row := range joinRows {
+		if i, ok := newIndexes[row.Timestamp]; ok {
+			newRows[i].FundingRate = row.FundingRate
+			newRows[i].OIChg = row.OIChg
+			continue
+		}
+		rejoined = append(rejoined, row)
+	}
+	return rejoined, nil
+}
+
+// fixedFeaturesState is the saved state of the fixed feature columns.
+type fixedFeaturesState struct {
+	Builder     *Builder             `json:"builder"`
+	Derivatives derivativesJoinState `json:"derivatives"`
+}
+
+// derivativesJoinState records the funding/open interest history the stored rows were joined
+// with, so samples that arrive later are re-joined onto the rows they affect.
+type derivativesJoinState struct {
+	FuturesExchange    string `json:"futures_exchange"`
+	FuturesSymbol      string `json:"futures_symbol"`
+	OpenInterestPeriod string `json:"oi_period"`
+
+	FundingRates         int   `json:"funding_rates"`
+	LastFundingTime      int64 `json:"last_funding_time"`
+	OpenInterest         int   `json:"open_interest"`
+	LastOpenInterestTime int64 `json:"last_open_interest_time"`
+}
+
+func newDerivativesJoinState(futuresExchange string, futuresSymbol string, openInterestPeriod string, fundingRates []derivatives.FundingRate, openInterest []derivatives.OpenInterest) derivativesJoinState {
+	state := derivativesJoinState{
+		FuturesExchange:    futuresExchange,
+		FuturesSymbol:      futuresSymbol,
+		OpenInterestPeriod: openInterestPeriod,
+		FundingRates:       len(fundingRates),
+		OpenInterest:       len(openInterest),
+	}
+	if len(fundingRates) > 0 {
+		state.LastFundingTime = fundingRates[len(fundingRates)-1].FundingTime
+	}
+	if len(openInterest) > 0 {
+		state.LastOpenInterestTime = openInterest[len(openInterest)-1].Timestamp
+	}
+	return state
+}
+
+// rejoinFrom is the open time of the first bar whose funding/open interest join may differ from
+// the stored rows: 0 when the joined history changed, math.MaxInt64 when nothing new arrived.
+func (saved derivativesJoinState) rejoinFrom(current derivativesJoinState, timeframe candles.Timeframe, fundingRates []derivatives.FundingRate, openInterest []derivatives.OpenInterest) int64 {
+	if saved.FuturesExchange != current.FuturesExchange || saved.FuturesSymbol != current.FuturesSymbol ||
+		saved.OpenInterestPeriod != current.OpenInterestPeriod {
+		return 0
+	}
+
+	from := int64(math.MaxInt64)
+	var kept int
+	for _, rate := range fundingRates {
+		if rate.FundingTime <= saved.LastFundingTime {
+			kept++
+		} else {
+			from = min(from, timeframe.Floor(rate.FundingTime))
+			break
+		}
+	}
+	if kept != saved.FundingRates {
+		return 0
+	}
+
+	kept = 0
+	for _, sample := range openInterest {
+		if sample.Timestamp <= saved.LastOpenInterestTime {
+			kept++
+		} else {
+			from = min(from, timeframe.Floor(sample.Timestamp))
+			break
+		}
+	}
+	if kept != saved.OpenInterest {
+		return 0
+	}
+	return from
+}
+
+// crossTimeframeState is the saved state of a cross-timeframe registry spec. LastTimestamp and
+// Candles of its StreamState refer to the source series; TargetLast and TargetCandles track the
+// target series the same way, so a backfilled target bar restarts the join.
+type crossTimeframeState struct {
+	Calculator json.RawMessage `json:"calculator"`
+
+	// Source values that later target bars can still join: the newest one closed by the end of
+	// TargetLast and every later one
+	Recent        []sourceValue `json:"recent"`
+	TargetLast    int64         `json:"target_last"`    // open time of the newest target bar joined
+	TargetCandles int           `json:"target_candles"` // target bars up to TargetLast
+}
+
+type sourceValue struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// streamStates indexes the saved states of one series by stream.
+type streamStates map[string]StreamState
+
+func streamStateKey(name string, params string) string {
+	return name + "(" + params + ")"
+}
+
+// resume returns the saved state of a stream when its source series still holds exactly the
+// candles the stream consumed; ok is false when the stream has to start over.
+func (states streamStates) resume(ctx context.Context, history History, name string, params string, sourceTimeframe string) (StreamState, bool, error) {
+	state, ok := states[streamStateKey(name, params)]
+	if !ok {
+		return StreamState{}, false, nil
+	}
+	count, err := history.CountCandlesThrough(ctx, sourceTimeframe, state.LastTimestamp)
+	if err != nil {
+		return StreamState{}, false, err
+	}
+	return state, count == state.Candles, nil
+}
+
+// registryStream is one registry spec being resumed or started over.
+type registryStream struct {
+	spec       Spec // ForSeries applied
+	calculator Calculator
+	state      StreamState // Candles is 0 when starting over
+	resumed    bool
+
+	// Cross-timeframe specs only
+	targetTimeframe candles.Timeframe
+	sourceTimeframe candles.Timeframe
+	cross           crossTimeframeState
+	newValues       []FeatureValue // source values computed this run
+	joinFrom        int64          // first target bar to (re-)join
+}
+
+func newRegistryStream(ctx context.Context, history History, run IncrementalRun, states streamStates, spec Spec) (*registryStream, error) {
+	spec = spec.ForSeries(run.Timeframe)
+	sourceTimeframe := run.Timeframe
+	if spec.Timeframe != "" {
+		sourceTimeframe = spec.Timeframe
+	}
+
+	stream := &registryStream{spec: spec, state: StreamState{Exchange: run.Exchange, Symbol: run.Symbol}}
+	state, resumed, err := states.resume(ctx, history, spec.StoredName(), spec.ParamsString(), sourceTimeframe)
+	if err != nil {
+		return nil, err
+	}
+	if resumed && spec.Timeframe != "" {
+		if err := json.Unmarshal([]byte(state.State), &stream.cross); err != nil {
+			return nil, fmt.Errorf("restore %s state: %w (rerun with --full)", spec, err)
+		}
+		// Recent only covers target bars after TargetLast, so a target bar backfilled before it
+		// can only be joined by starting over
+		count, err := history.CountCandlesThrough(ctx, run.Timeframe, stream.cross.TargetLast)
+		if err != nil {
+			return nil, err
+		}
+		resumed = count == stream.cross.TargetCandles
+	}
+	if resumed {
+		calculatorState := []byte(state.State)
+		if spec.Timeframe != "" {
+			calculatorState = stream.cross.Calculator
+		}
+		if stream.calculator, err = spec.Source().RestoreCalculator(calculatorState); err != nil {
+			return nil, fmt.Errorf("%w (rerun with --full)", err)
+		}
+		stream.state, stream.resumed = state, true
+	} else {
+		stream.cross = crossTimeframeState{}
+		if stream.calculator, err = spec.Source().NewCalculator(); err != nil {
+			return nil, err
+		}
+	}
+
+	if spec.Timeframe == "" {
+		return stream, nil
+	}
+
+	// Cross-timeframe: advance over the new source candles now; the target bars to join follow
+	if stream.targetTimeframe, err = candles.ParseTimeframe(run.Timeframe); err != nil {
+		return nil, err
+	}
+	if stream.sourceTimeframe, err = candles.ParseTimeframe(spec.Timeframe); err != nil {
+		return nil, err
+	}
+	sourceSeries, err := history.CandlesSince(ctx, spec.Timeframe, stream.from())
+	if err != nil {
+		return nil, err
+	}
+	if !stream.resumed && len(sourceSeries) == 0 {
+		return nil, fmt.Errorf("%s needs %s candles for %s (ingest or resample them first)", spec, spec.Timeframe, run.Symbol)
+	}
+	stream.newValues = RunCalculator(stream.calculator, sourceSeries, spec.Source())
+	stream.advance(sourceSeries)
+
+	stream.joinFrom = 0
+	if stream.resumed {
+		stream.joinFrom = stream.cross.TargetLast + 1
+		if len(stream.newValues) > 0 {
+			// Target bars closing after the first new source close may join it
+			firstClose := stream.sourceTimeframe.CloseTime(stream.newValues[0].Timestamp)
+			stream.joinFrom = min(stream.joinFrom, stream.targetTimeframe.Floor(firstClose+1))
+		}
+	}
+	return stream, nil
+}
+
+// from is the open time of the first source candle the stream has not consumed.
+func (stream *registryStream) from() int64 {
+	if !stream.resumed {
+		return 0
+	}
+	return stream.state.LastTimestamp + 1
+}
+
+// advance records the source candles fed to the calculator.
+func (stream *registryStream) advance(sourceSeries []candles.Candle) {
+	if len(sourceSeries) == 0 {
+		return
+	}
+	stream.state.LastTimestamp = sourceSeries[len(sourceSeries)-1].Timestamp
+	stream.state.Candles += len(sourceSeries)
+}
+
+// run feeds the target candles (open time >= the smallest from/joinFrom of all streams) to the
+// stream and returns its feature values.
+func (stream *registryStream) run(targetSeries []candles.Candle) ([]FeatureValue, error) {
+	if stream.spec.Timeframe == "" {
+		newCandles := candlesSince(targetSeries, stream.from())
+		values := RunCalculator(stream.calculator, newCandles, stream.spec)
+		stream.advance(newCandles)
+		return values, nil
+	}
+
+	sourceValues := make([]FeatureValue, 0, len(stream.cross.Recent)+len(stream.newValues))
+	for _, recent := range stream.cross.Recent {
+		sourceValues = append(sourceValues, FeatureValue{
+			Exchange:  stream.state.Exchange,
+			Symbol:    stream.state.Symbol,
+			Timeframe: stream.sourceTimeframe.String(),
+			Timestamp: recent.Timestamp,
+			Name:      stream.spec.Name,
+			Params:    stream.spec.ParamsString(),
+			Value:     recent.Value,
+		})
+	}
+	sourceValues = append(sourceValues, stream.newValues...)
+
+	joinSeries := candlesSince(targetSeries, stream.joinFrom)
+	joined, err := JoinCrossTimeframe(joinSeries, sourceValues, stream.spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// targetSeries holds every target bar after TargetLast: the join never starts later
+	for _, candle := range targetSeries {
+		if candle.Timestamp > stream.cross.TargetLast {
+			stream.cross.TargetCandles++
+		}
+	}
+	if len(joinSeries) > 0 {
+		stream.cross.TargetLast = max(stream.cross.TargetLast, joinSeries[len(joinSeries)-1].Timestamp)
+	}
+	if len(sourceValues) > 0 {
+		targetLastClose := stream.targetTimeframe.CloseTime(stream.cross.TargetLast)
+		keep := 0
+		for i, value := range sourceValues {
+			if stream.sourceTimeframe.CloseTime(value.Timestamp) <= targetLastClose {
+				keep = i
+			}
+		}
+		stream.cross.Recent = stream.cross.Recent[:0]
+		for _, value := range sourceValues[keep:] {
+			stream.cross.Recent = append(stream.cross.Recent, sourceValue{Timestamp: value.Timestamp, Value: value.Value})
+		}
+	}
+	return joined, nil
+}
+
+// savedState is the stream's state after the run; ok is false while it has not consumed any
+// candle.
+func (stream *registryStream) savedState(run IncrementalRun) (StreamState, bool, error) {
+	if stream.state.Candles == 0 {
+		return StreamState{}, false, nil
+	}
+	state, err := MarshalCalculator(stream.calculator)
+	if err != nil {
+		return StreamState{}, false, err
+	}
+	if stream.spec.Timeframe != "" {
+		stream.cross.Calculator = state
+		if state, err = json.Marshal(stream.cross); err != nil {
+			return StreamState{}, false, err
+		}
+	}
+	return StreamState{
+		Exchange:      stream.state.Exchange,
+		Symbol:        stream.state.Symbol,
+		Timeframe:     run.Timeframe,
+		Name:          stream.spec.StoredName(),
+		Params:        stream.spec.ParamsString(),
+		LastTimestamp: stream.state.LastTimestamp,
+		Candles:       stream.state.Candles,
+		State:         string(state),
+	}, true, nil
+}
+
+// candlesSince returns the tail of an ordered series with open time >= from.
+func candlesSince(candleSeries []candles.Candle, from int64) []candles.Candle {
+	for i, candle := range candleSeries {
+		if candle.Timestamp >= from {
+			return candleSeries[i:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/derivatives"
+)
+
+// memoryHistory is an in-memory History over the candles of one series per timeframe.
+type memoryHistory map[string][]candles.Candle
+
+func (history memoryHistory) CandlesSince(ctx context.Context, timeframe string, from int64) ([]candles.Candle, error) {
+	return candlesSince(history[timeframe], from), nil
+}
+
+func (history memoryHistory) CountCandlesThrough(ctx context.Context, timeframe string, through int64) (int, error) {
+	count := 0
+	for _, candle := range history[timeframe] {
+		if candle.Timestamp <= through {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (history memoryHistory) LatestCandleBefore(ctx context.Context, timeframe string, before int64) (int64, bool, error) {
+	var latest int64
+	found := false
+	for _, candle := range history[timeframe] {
+		if candle.Timestamp < before {
+			latest, found = candle.Timestamp, true
+		}
+	}
+	return latest, found, nil
+}
+
+// memoryFeatures applies run results the way the store does: restarted streams lose what they
+// stored, rows and values are upserted, re-joined rows only update funding and open interest,
+// and states replace the saved ones.
+type memoryFeatures struct {
+	rows   map[int64]FeatureRow
+	values map[string]float64
+	states []StreamState
+}
+
+func newMemoryFeatures() *memoryFeatures {
+	return &memoryFeatures{rows: map[int64]FeatureRow{}, values: map[string]float64{}}
+}
+
+func (stored *memoryFeatures) apply(result IncrementalResult) {
+	if result.RowsRestarted {
+		clear(stored.rows)
+	}
+	for _, spec := range result.ValuesRestarted {
+		prefix := fmt.Sprintf("%s(%s)@", spec.StoredName(), spec.ParamsString())
+		for key := range stored.values {
+			if strings.HasPrefix(key, prefix) {
+				delete(stored.values, key)
+			}
+		}
+	}
+	for _, row := range result.Rows {
+		stored.rows[row.Timestamp] = row
+	}
+	for _, rejoined := range result.Rejoined {
+		row := stored.rows[rejoined.Timestamp]
+		row.FundingRate, row.OIChg = rejoined.FundingRate, rejoined.OIChg
+		stored.rows[rejoined.Timestamp] = row
+	}
+	for _, value := range result.Values {
+		stored.values[fmt.Sprintf("%s(%s)@%d", value.Name, value.Params, value.Timestamp)] = value.Value
+	}
+	stored.states = result.States
+}
+
+func syntheticCandles(timeframe string, start int64, count int) []candles.Candle {
+	parsed, err := candles.ParseTimeframe(timeframe)
+	if err != nil {
+		panic(err)
+	}
+	series := make([]candles.Candle, count)
+	open := start
+	for i := range series {
+		closePrice := 100 + 10*math.Sin(float64(i)/3) + 0.2*float64(i)
+		series[i] = candles.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Timeframe: timeframe, Timestamp: open,
+			Open: closePrice - 0.5, High: closePrice + 1 + math.Abs(math.Cos(float64(i))), Low: closePrice - 1.5, Close: closePrice,
+			Volume: 10 + 5*math.Cos(float64(i)/2), CloseTime: parsed.CloseTime(open), IsFinal: true,
+		}
+		open = parsed.Next(open)
+	}
+	return series
+}
+
+// without drops the candles at the given indexes, leaving gaps.
+func without(series []candles.Candle, indexes ...int) []candles.Candle {
+	var kept []candles.Candle
+	for i, candle := range series {
+		if !slices.Contains(indexes, i) {
+			kept = append(kept, candle)
+		}
+	}
+	return kept
+}
+
+func TestRunIncrementalMatchesFullRebuild(t *testing.T) {
+	const start = 1709251200000 // 2024-03-01 00:00 UTC
+	const eightHours = 8 * 60 * 60 * 1000
+	fourHour := syntheticCandles("4h", start, 120) // 20 days
+	daily := syntheticCandles("1d", start-5*24*60*60*1000, 25)
+
+	var fundingRates []derivatives.FundingRate
+	for i := range 60 {
+		fundingRates = append(fundingRates, derivatives.FundingRate{
+			Exchange: "binance_usdm", Symbol: "BTCUSDT", FundingTime: start + int64(i)*eightHours, Rate: 0.0001 * float64(i%7-3),
+		})
+	}
+	// One sample per 4h bar, starting a day into the candles
+	const fourHours = eightHours / 2
+	var openInterest []derivatives.OpenInterest
+	for i := range 114 {
+		openInterest = append(openInterest, derivatives.OpenInterest{
+			Exchange: "binance_usdm", Symbol: "BTCUSDT", Period: "4h", Timestamp: start + int64(6+i)*fourHours,
+			SumOpenInterest: 1000 + 50*math.Sin(float64(i)/4), SumOpenInterestValue: 6e7 + 1e6*float64(i%9),
+		})
+	}
+
+	specs, err := ParseSpecs([]string{"ema(5)", "rsi(3)", "1d:ema(3)", "1d:vol_parkinson(2)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each step is the stored history (and funding and open interest known) at one incremental run
+	type step struct {
+		fourHour     []candles.Candle
+		daily        []candles.Candle
+		fundingRates int
+		openInterest int
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"append only", []step{
+			{fourHour[:40], daily[:12], 20, 30},
+			{fourHour[:41], daily[:12], 20, 35},
+			{fourHour[:80], daily[:19], 40, 74},
+			{fourHour, daily, 60, 114},
+			{fourHour, daily, 60, 114},
+		}},
+		{"target gap backfilled", []step{
+			{without(fourHour[:60], 20, 21, 22), daily[:15], 30, 50},
+			{fourHour[:60], daily[:15], 30, 50},
+			{fourHour, daily, 60, 114},
+		}},
+		{"target gap backfilled with new source bars", []step{
+			{without(fourHour[:60], 30), daily[:15], 30, 54},
+			{without(fourHour, 30), daily, 60, 114},
+			{fourHour, daily, 60, 114},
+		}},
+		{"source gap backfilled", []step{
+			{fourHour[:60], without(daily[:15], 8), 30, 54},
+			{fourHour, without(daily, 8), 60, 114},
+			{fourHour, daily, 60, 114},
+		}},
+		// Open interest that arrives after the bars it belongs to changes oi_chg of stored rows
+		{"open interest arrives late", []step{
+			{fourHour[:60], daily[:15], 30, 0},
+			{fourHour[:60], daily[:15], 30, 20},
+			{fourHour[:80], daily[:19], 40, 40},
+			{fourHour, daily, 60, 114},
+		}},
+		// Removed history restarts the streams reading it; bars they no longer fill lose their
+		// stored rows and values
+		{"target bars removed", []step{
+			{fourHour, daily, 60, 114},
+			{without(fourHour, 50, 51, 52), daily, 60, 114},
+		}},
+		{"source tail removed", []step{
+			{fourHour, daily, 60, 114},
+			{fourHour, daily[:15], 60, 114},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			incremental := newMemoryFeatures()
+			for i, step := range test.steps {
+				history := memoryHistory{"4h": step.fourHour, "1d": step.daily}
+				run := IncrementalRun{
+					Exchange: "binance", Symbol: "BTCUSDT", Timeframe: "4h", Specs: specs,
+					FuturesExchange: "binance_usdm", FuturesSymbol: "BTCUSDT", OpenInterestPeriod: "4h",
+					FundingRates: fundingRates[:step.fundingRates], OpenInterest: openInterest[:step.openInterest],
+				}
+
+				run.Saved = incremental.states
+				result, err := RunIncremental(ctx, history, run)
+				if err != nil {
+					t.Fatalf("step %d incremental: %v", i, err)
+				}
+				incremental.apply(result)
+
+				run.Saved = nil
+				full := newMemoryFeatures()
+				result, err = RunIncremental(ctx, history, run)
+				if err != nil {
+					t.Fatalf("step %d full: %v", i, err)
+				}
+				full.apply(result)
+
+				if !reflect.DeepEqual(incremental.rows, full.rows) {
+					t.Errorf("step %d: incremental feature rows differ from a full rebuild", i)
+				}
+				if !reflect.DeepEqual(incremental.values, full.values) {
+					for key, value := range full.values {
+						if got, ok := incremental.values[key]; !ok || got != value {
+							t.Errorf("step %d: %s = %v (stored %v), full rebuild %v", i, key, got, ok, value)
+						}
+					}
+					t.Fatalf("step %d: incremental feature values differ from a full rebuild", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRunIncrementalResumesUnchangedStreams(t *testing.T) {
+	const start = 1709251200000
+	history := memoryHistory{"4h": syntheticCandles("4h", start, 30), "1d": syntheticCandles("1d", start-24*60*60*1000, 6)}
+	specs, err := ParseSpecs([]string{"ema(5)", "1d:ema(3)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := IncrementalRun{Exchange: "binance", Symbol: "BTCUSDT", Timeframe: "4h", Specs: specs}
+
+	first, err := RunIncremental(context.Background(), history, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Resumed != 0 || first.Streams != 3 || len(first.States) != 3 {
+		t.Fatalf("first run resumed %d/%d streams with %d states, want 0/3 with 3", first.Resumed, first.Streams, len(first.States))
+	}
+
+	run.Saved = first.States
+	second, err := RunIncremental(context.Background(), history, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Resumed != 3 || len(second.Rows) != 0 || len(second.Values) != 0 {
+		t.Fatalf("rerun resumed %d streams, %d rows, %d values; want 3 streams and nothing new", second.Resumed, len(second.Rows), len(second.Values))
+	}
+}
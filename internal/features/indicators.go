@@ -0,0 +1,401 @@
+package features
+
+import (
+	"math"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+// Classic technical indicators as registry calculators. Each stays in warm-up (no value) until
+// its full lookback has been seen; smoothed indicators use Wilder's method like the reference
+// definitions.
+func init() {
+	Register(Definition{
+		Name:        "rsi",
+		Params:      []string{"period"},
+		Defaults:    []float64{14},
+		Description: "Relative strength index (Wilder), 0-100",
+		New: func(params []float64) (Calculator, error) {
+			period, err := periodParam("period", params[0], 1)
+			if err != nil {
+				return nil, err
+			}
+			return &rsiCalculator{Gains: newWilderAverage(period), Losses: newWilderAverage(period)}, nil
+		},
+	})
+	for _, output := range []string{macdLine, macdSignal, macdHistogram} {
+		Register(Definition{
+			Name:        output,
+			Params:      []string{"fast", "slow", "signal"},
+			Defaults:    []float64{12, 26, 9},
+			Description: macdDescriptions[output],
+			New: func(params []float64) (Calculator, error) {
+				return newMACDCalculator(output, params)
+			},
+		})
+	}
+	for _, output := range []string{bollingerPercentB, bollingerBandwidth} {
+		Register(Definition{
+			Name:        output,
+			Params:      []string{"period", "k"},
+			Defaults:    []float64{20, 2},
+			Description: bollingerDescriptions[output],
+			New: func(params []float64) (Calculator, error) {
+				return newBollingerCalculator(output, params)
+			},
+		})
+	}
+	Register(Definition{
+		Name:        "atr",
+		Params:      []string{"period"},
+		Defaults:    []float64{14},
+		Description: "Average true range (Wilder)",
+		New: func(params []float64) (Calculator, error) {
+			period, err := periodParam("period", params[0], 1)
+			if err != nil {
+				return nil, err
+			}
+			return &atrCalculator{TrueRanges: newWilderAverage(period)}, nil
+		},
+	})
+	Register(Definition{
+		Name:        "stoch_k",
+		Params:      []string{"period"},
+		Defaults:    []float64{14},
+		Description: "Stochastic %K: 100 * (close - lowest low) / (highest high - lowest low)",
+		New: func(params []float64) (Calculator, error) {
+			period, err := periodParam("period", params[0], 1)
+			if err != nil {
+				return nil, err
+			}
+			return newStochasticCalculator(period, 1), nil
+		},
+	})
+	Register(Definition{
+		Name:        "stoch_d",
+		Params:      []string{"period", "smooth"},
+		Defaults:    []float64{14, 3},
+		Description: "Stochastic %D: simple average of the last smooth %K values",
+		New: func(params []float64) (Calculator, error) {
+			period, err := periodParam("period", params[0], 1)
+			if err != nil {
+				return nil, err
+			}
+			smooth, err := periodParam("smooth", params[1], 1)
+			if err != nil {
+				return nil, err
+			}
+			return newStochasticCalculator(period, smooth), nil
+		},
+	})
+	Register(Definition{
+		Name:        "adx",
+		Params:      []string{"period"},
+		Defaults:    []float64{14},
+		Description: "Average directional index (Wilder), 0-100",
+		New: func(params []float64) (Calculator, error) {
+			period, err := periodParam("period", params[0], 1)
+			if err != nil {
+				return nil, err
+			}
+			return &adxCalculator{
+				TrueRanges:    newWilderAverage(period),
+				PlusMoves:     newWilderAverage(period),
+				MinusMoves:    newWilderAverage(period),
+				DirectionalIx: newWilderAverage(period),
+			}, nil
+		},
+	})
+	Register(Definition{
+		Name:        "obv",
+		Description: "On-balance volume, cumulative from the first stored bar",
+		New: func(params []float64) (Calculator, error) {
+			return &obvCalculator{}, nil
+		},
+	})
+}
+
+// wilderAverage is Wilder's smoothing: the simple mean of the first Period values, then
+// value = (value*(Period-1) + x) / Period.
+type wilderAverage struct {
+	Period int     `json:"period"`
+	Count  int     `json:"count"`
+	Value  float64 `json:"value"` // running sum until Period values have been seen
+}
+
+func newWilderAverage(period int) wilderAverage {
+	return wilderAverage{Period: period}
+}
+
+func (average *wilderAverage) Update(x float64) (float64, bool) {
+	average.Count++
+	switch {
+	case average.Count < average.Period:
+		average.Value += x
+		return 0, false
+	case average.Count == average.Period:
+		average.Value = (average.Value + x) / float64(average.Period)
+	default:
+		average.Value = (average.Value*float64(average.Period-1) + x) / float64(average.Period)
+	}
+	return average.Value, true
+}
+
+// trueRange tracks the previous bar for true range and directional movement.
+type trueRange struct {
+	PreviousHigh  float64 `json:"previous_high"`
+	PreviousLow   float64 `json:"previous_low"`
+	PreviousClose float64 `json:"previous_close"`
+	HasPrevious   bool    `json:"has_previous"`
+}
+
+// Update returns max(high-low, |high-prevClose|, |low-prevClose|) and the up/down moves
+// high-prevHigh and prevLow-low; ok is false on the first bar.
+func (tracker *trueRange) Update(candle candles.Candle) (value float64, upMove float64, downMove float64, ok bool) {
+	previous := *tracker
+	tracker.PreviousHigh, tracker.PreviousLow, tracker.PreviousClose, tracker.HasPrevious = candle.High, candle.Low, candle.Close, true
+	if !previous.HasPrevious {
+		return 0, 0, 0, false
+	}
+	value = math.Max(candle.High-candle.Low, math.Max(math.Abs(candle.High-previous.PreviousClose), math.Abs(candle.Low-previous.PreviousClose)))
+	return value, candle.High - previous.PreviousHigh, previous.PreviousLow - candle.Low, true
+}
+
+type rsiCalculator struct {
+	Change closeChange   `json:"change"`
+	Gains  wilderAverage `json:"gains"`
+	Losses wilderAverage `json:"losses"`
+}
+
+func (calculator *rsiCalculator) Update(candle candles.Candle) (float64, bool) {
+	change, ok := calculator.Change.Difference(candle.Close)
+	if !ok {
+		return 0, false
+	}
+
+	averageGain, ok := calculator.Gains.Update(math.Max(change, 0))
+	averageLoss, _ := calculator.Losses.Update(math.Max(-change, 0))
+	if !ok {
+		return 0, false
+	}
+	if averageLoss == 0 {
+		if averageGain == 0 {
+			return 50, true
+		}
+		return 100, true
+	}
+	return 100 - 100/(1+averageGain/averageLoss), true
+}
+
+const (
+	macdLine      = "macd"
+	macdSignal    = "macd_signal"
+	macdHistogram = "macd_hist"
+)
+
+var macdDescriptions = map[string]string{
+	macdLine:      "MACD line: ema(fast) - ema(slow)",
+	macdSignal:    "MACD signal: ema(signal) of the MACD line",
+	macdHistogram: "MACD histogram: line - signal",
+}
+
+type macdCalculator struct {
+	Output string         `json:"output"`
+	Fast   *emaCalculator `json:"fast"`
+	Slow   *emaCalculator `json:"slow"`
+	Signal *emaCalculator `json:"signal"`
+}
+
+func newMACDCalculator(output string, params []float64) (*macdCalculator, error) {
+	fast, err := periodParam("fast", params[0], 1)
+	if err != nil {
+		return nil, err
+	}
+	slow, err := periodParam("slow", params[1], 1)
+	if err != nil {
+		return nil, err
+	}
+	signal, err := periodParam("signal", params[2], 1)
+	if err != nil {
+		return nil, err
+	}
+	return &macdCalculator{
+		Output: output,
+		Fast:   newEMACalculator(fast),
+		Slow:   newEMACalculator(slow),
+		Signal: newEMACalculator(signal),
+	}, nil
+}
+
+// Update starts the signal EMA on the first complete MACD line value.
+func (calculator *macdCalculator) Update(candle candles.Candle) (float64, bool) {
+	fast, fastOK := calculator.Fast.Update(candle)
+	slow, slowOK := calculator.Slow.Update(candle)
+	if !fastOK || !slowOK {
+		return 0, false
+	}
+	line := fast - slow
+	if calculator.Output == macdLine {
+		return line, true
+	}
+
+	signal, ok := calculator.Signal.Update(candles.Candle{Close: line})
+	if !ok {
+		return 0, false
+	}
+	if calculator.Output == macdSignal {
+		return signal, true
+	}
+	return line - signal, true
+}
+
+const (
+	bollingerPercentB  = "bb_pctb"
+	bollingerBandwidth = "bb_width"
+)
+
+var bollingerDescriptions = map[string]string{
+	bollingerPercentB:  "Bollinger %B: (close - lower) / (upper - lower)",
+	bollingerBandwidth: "Bollinger bandwidth: (upper - lower) / middle",
+}
+
+type bollingerCalculator struct {
+	Output string        `json:"output"`
+	K      float64       `json:"k"`
+	Closes rollingWindow `json:"closes"`
+}
+
+func newBollingerCalculator(output string, params []float64) (*bollingerCalculator, error) {
+	period, err := periodParam("period", params[0], 2)
+	if err != nil {
+		return nil, err
+	}
+	k, err := positiveParam("k", params[1])
+	if err != nil {
+		return nil, err
+	}
+	return &bollingerCalculator{Output: output, K: k, Closes: newRollingWindow(period)}, nil
+}
+
+// Update uses the population standard deviation of closes; a flat window has no bands.
+func (calculator *bollingerCalculator) Update(candle candles.Candle) (float64, bool) {
+	calculator.Closes.Push(candle.Close)
+	if !calculator.Closes.Full() {
+		return 0, false
+	}
+	middle := calculator.Closes.Mean()
+	width := 2 * calculator.K * calculator.Closes.Std()
+	if width == 0 {
+		return 0, false
+	}
+	if calculator.Output == bollingerBandwidth {
+		return width / middle, true
+	}
+	lower := middle - width/2
+	return (candle.Close - lower) / width, true
+}
+
+type atrCalculator struct {
+	Range      trueRange     `json:"range"`
+	TrueRanges wilderAverage `json:"true_ranges"`
+}
+
+func (calculator *atrCalculator) Update(candle candles.Candle) (float64, bool) {
+	trueRangeValue, _, _, ok := calculator.Range.Update(candle)
+	if !ok {
+		return 0, false
+	}
+	return calculator.TrueRanges.Update(trueRangeValue)
+}
+
+type stochasticCalculator struct {
+	Highs   rollingWindow `json:"highs"`
+	Lows    rollingWindow `json:"lows"`
+	KValues rollingWindow `json:"k_values"` // the last smooth %K values (1 for stoch_k)
+}
+
+func newStochasticCalculator(period int, smooth int) *stochasticCalculator {
+	return &stochasticCalculator{
+		Highs:   newRollingWindow(period),
+		Lows:    newRollingWindow(period),
+		KValues: newRollingWindow(smooth),
+	}
+}
+
+// Update skips %K on bars where the window is flat (highest high == lowest low).
+func (calculator *stochasticCalculator) Update(candle candles.Candle) (float64, bool) {
+	calculator.Highs.Push(candle.High)
+	calculator.Lows.Push(candle.Low)
+	if !calculator.Highs.Full() {
+		return 0, false
+	}
+
+	highest, lowest := calculator.Highs.Values[0], calculator.Lows.Values[0]
+	for i := range calculator.Highs.Values {
+		highest = math.Max(highest, calculator.Highs.Values[i])
+		lowest = math.Min(lowest, calculator.Lows.Values[i])
+	}
+	if highest == lowest {
+		return 0, false
+	}
+	calculator.KValues.Push(100 * (candle.Close - lowest) / (highest - lowest))
+	if !calculator.KValues.Full() {
+		return 0, false
+	}
+	return calculator.KValues.Mean(), true
+}
+
+type adxCalculator struct {
+	Range         trueRange     `json:"range"`
+	TrueRanges    wilderAverage `json:"true_ranges"`
+	PlusMoves     wilderAverage `json:"plus_moves"`
+	MinusMoves    wilderAverage `json:"minus_moves"`
+	DirectionalIx wilderAverage `json:"directional_index"`
+}
+
+func (calculator *adxCalculator) Update(candle candles.Candle) (float64, bool) {
+	trueRangeValue, upMove, downMove, ok := calculator.Range.Update(candle)
+	if !ok {
+		return 0, false
+	}
+
+	plusMove, minusMove := 0.0, 0.0
+	if upMove > downMove && upMove > 0 {
+		plusMove = upMove
+	}
+	if downMove > upMove && downMove > 0 {
+		minusMove = downMove
+	}
+
+	averageRange, ok := calculator.TrueRanges.Update(trueRangeValue)
+	averagePlus, _ := calculator.PlusMoves.Update(plusMove)
+	averageMinus, _ := calculator.MinusMoves.Update(minusMove)
+	if !ok || averageRange == 0 {
+		return 0, false
+	}
+
+	plusDI := 100 * averagePlus / averageRange
+	minusDI := 100 * averageMinus / averageRange
+	dx := 0.0
+	if plusDI+minusDI > 0 {
+		dx = 100 * math.Abs(plusDI-minusDI) / (plusDI + minusDI)
+	}
+	return calculator.DirectionalIx.Update(dx)
+}
+
+type obvCalculator struct {
+	Change closeChange `json:"change"`
+	Value  float64     `json:"value"`
+}
+
+func (calculator *obvCalculator) Update(candle candles.Candle) (float64, bool) {
+	change, ok := calculator.Change.Difference(candle.Close)
+	switch {
+	case !ok:
+	case change > 0:
+		calculator.Value += candle.Volume
+	case change < 0:
+		calculator.Value -= candle.Volume
+	}
+	return calculator.Value, true
+}
@@ -0,0 +1,160 @@
+package features
+
+import (
+	"math"
+	"testing"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+// none marks a bar with no value (warm-up or undefined) in the expected series.
+var none = math.NaN()
+
+const fourHours = 4 * 60 * 60 * 1000
+
+// bar is one test candle; an omitted open defaults to the close.
+type bar struct {
+	open, high, low, close, volume float64
+}
+
+func testSeries(bars []bar) []candles.Candle {
+	series := make([]candles.Candle, len(bars))
+	for i, b := range bars {
+		open := b.open
+		if open == 0 {
+			open = b.close
+		}
+		timestamp := int64(1709251200000 + i*fourHours)
+		series[i] = candles.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Timeframe: "4h", Timestamp: timestamp,
+			Open: open, High: b.high, Low: b.low, Close: b.close, Volume: b.volume,
+			CloseTime: timestamp + fourHours - 1, IsFinal: true,
+		}
+	}
+	return series
+}
+
+// closeSeries is flat bars (open = high = low = close) with unit volume.
+func closeSeries(closes ...float64) []bar {
+	bars := make([]bar, len(closes))
+	for i, close := range closes {
+		bars[i] = bar{high: close, low: close, close: close, volume: 1}
+	}
+	return bars
+}
+
+type knownAnswer struct {
+	name string
+	spec string
+	bars []bar
+	want []float64 // one per bar; none where the calculator reports no value
+}
+
+func checkKnownAnswers(t *testing.T, tests []knownAnswer) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec, err := ParseSpec(test.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			calculator, err := spec.NewCalculator()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(test.want) != len(test.bars) {
+				t.Fatalf("test has %d bars but %d expected values", len(test.bars), len(test.want))
+			}
+			for i, candle := range testSeries(test.bars) {
+				got, ok := calculator.Update(candle)
+				want := test.want[i]
+				switch {
+				case math.IsNaN(want) && ok:
+					t.Errorf("bar %d: got %v, want no value", i, got)
+				case !math.IsNaN(want) && !ok:
+					t.Errorf("bar %d: no value, want %v", i, want)
+				case ok && math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)):
+					t.Errorf("bar %d: got %.12g, want %.12g", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// ohlcBars share one high/low/close path for the range-based indicators.
+var ohlcBars = []bar{
+	{high: 10, low: 8, close: 9, volume: 1},
+	{high: 12, low: 9, close: 11, volume: 1},
+	{high: 11, low: 10, close: 10.5, volume: 1},
+	{high: 15, low: 11, close: 14, volume: 1},
+	{high: 14, low: 9, close: 10, volume: 1},
+}
+
+func TestIndicatorKnownAnswers(t *testing.T) {
+	// MACD(2,3,2) on closes 1..5: ema(2) = 1, 5/3, 23/9, 95/27, 365/81 and
+	// ema(3) = 1, 1.5, 2.25, 3.125, 4.0625; the signal EMA seeds on the first line value
+	line := []float64{none, none, 11.0 / 36, 85.0 / 216, 575.0 / 1296}
+	signal := []float64{none, none, none, 59.0 / 162, 1622.0 / 3888}
+	histogram := []float64{none, none, none, 19.0 / 648, 103.0 / 3888}
+
+	// Bollinger(3,2) on closes 1,2,3,6: windows {1,2,3} (std sqrt(2/3)) and {2,3,6} (std sqrt(26)/3)
+	firstWidth, secondWidth := 4*math.Sqrt(2.0/3), 4*math.Sqrt(26)/3
+	percentB := []float64{none, none, (3 - (2 - firstWidth/2)) / firstWidth, (6 - (11.0/3 - secondWidth/2)) / secondWidth}
+	bandwidth := []float64{none, none, firstWidth / 2, secondWidth / (11.0 / 3)}
+
+	checkKnownAnswers(t, []knownAnswer{
+		// Changes +1 +1 -1 +2 0: Wilder averages gain/loss 2/3,1/3 then 10/9,2/9 then 20/27,4/27
+		{"rsi", "rsi(3)", closeSeries(10, 11, 12, 11, 13, 13), []float64{none, none, none, 100 - 100.0/3, 100 - 100.0/6, 100 - 100.0/6}},
+		{"rsi only gains", "rsi(3)", closeSeries(1, 2, 3, 4), []float64{none, none, none, 100}},
+		{"rsi flat", "rsi(3)", closeSeries(5, 5, 5, 5), []float64{none, none, none, 50}},
+
+		{"macd line", "macd(2,3,2)", closeSeries(1, 2, 3, 4, 5), line},
+		{"macd signal", "macd_signal(2,3,2)", closeSeries(1, 2, 3, 4, 5), signal},
+		{"macd histogram", "macd_hist(2,3,2)", closeSeries(1, 2, 3, 4, 5), histogram},
+
+		{"bollinger %b", "bb_pctb(3,2)", closeSeries(1, 2, 3, 6), percentB},
+		{"bollinger width", "bb_width(3,2)", closeSeries(1, 2, 3, 6), bandwidth},
+		{"bollinger flat window", "bb_pctb(3,2)", closeSeries(5, 5, 5, 6), []float64{none, none, none, (6 - (16.0/3 - 2*math.Sqrt(2.0/9))) / (4 * math.Sqrt(2.0/9))}},
+
+		// True ranges 3, 1, 4.5, 5: Wilder(2) = 2, then (2+4.5)/2, then (3.25+5)/2
+		{"atr", "atr(2)", ohlcBars, []float64{none, none, 2, 3.25, 4.125}},
+		{"atr flat", "atr(2)", closeSeries(5, 5, 5), []float64{none, none, 0}},
+
+		// Windows of 3: (10.5-8)/(12-8), (14-9)/(15-9), (10-9)/(15-9)
+		{"stochastic %k", "stoch_k(3)", ohlcBars, []float64{none, none, 62.5, 250.0 / 3, 50.0 / 3}},
+		{"stochastic %d", "stoch_d(3,2)", ohlcBars, []float64{none, none, none, (62.5 + 250.0/3) / 2, (250.0/3 + 50.0/3) / 2}},
+		{"stochastic flat range", "stoch_k(2)", []bar{{high: 5, low: 5, close: 5}, {high: 5, low: 5, close: 5}, {high: 6, low: 5, close: 6}}, []float64{none, none, 100}},
+
+		// +DM 2,0,4,0 and -DM 0,0,0,2 against true ranges 3,1,4.5,5: DX 100, 100, then
+		// 100*|1.25-1|/(1.25+1) = 100/9, so ADX(2) = 100 then (100 + 100/9) / 2
+		{"adx", "adx(2)", ohlcBars, []float64{none, none, none, 100, (100 + 100.0/9) / 2}},
+		{"adx flat range", "adx(2)", closeSeries(5, 5, 5, 5), []float64{none, none, none, none}},
+
+		{"obv", "obv", []bar{
+			{high: 10, low: 10, close: 10, volume: 1},
+			{high: 11, low: 11, close: 11, volume: 2},
+			{high: 11, low: 11, close: 11, volume: 3},
+			{high: 9, low: 9, close: 9, volume: 4},
+			{high: 12, low: 12, close: 12, volume: 5},
+		}, []float64{0, 2, 2, -2, 3}},
+	})
+}
+
+func TestWilderAverage(t *testing.T) {
+	average := newWilderAverage(3)
+	inputs := []float64{3, 6, 9, 12, 0}
+	// Simple mean of the first 3, then (previous*2 + x) / 3
+	want := []float64{none, none, 6, 8, 16.0 / 3}
+	for i, input := range inputs {
+		got, ok := average.Update(input)
+		if math.IsNaN(want[i]) {
+			if ok {
+				t.Errorf("value %d: got %v during warm-up", i, got)
+			}
+			continue
+		}
+		if !ok || math.Abs(got-want[i]) > 1e-12 {
+			t.Errorf("value %d: got %v (ok=%v), want %v", i, got, ok, want[i])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package features
+
+import (
+	"fmt"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/derivatives"
+)
+
+// JoinCrossTimeframe copies source-timeframe feature values onto the target bars of the series.
+// A source value is known once its bar has closed, so each target bar gets the value of the
+// last source bar that closed before the target bar's close time: a 4h bar sees the 1d bar of
+// the previous day, even the 4h bar closing together with the day, and the 1h bar before its
+// own last one. A source value more than two source bars older than the target close (the
+// source series stopped or has a gap) is treated as missing. Both inputs must be in time
+// order; the result is keyed by the target bars and named spec.StoredName().
+func JoinCrossTimeframe(targetSeries []candles.Candle, sourceValues []FeatureValue, spec Spec) ([]FeatureValue, error) {
+	if len(targetSeries) == 0 || len(sourceValues) == 0 {
+		return nil, nil
+	}
+	targetTimeframe, err := candles.ParseTimeframe(targetSeries[0].Timeframe)
+	if err != nil {
+		return nil, err
+	}
+	sourceTimeframe, err := candles.ParseTimeframe(spec.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+	if sourceValues[0].Timeframe != sourceTimeframe.String() {
+		return nil, fmt.Errorf("join %s: source values are %s, not %s", spec, sourceValues[0].Timeframe, sourceTimeframe)
+	}
+
+	// Close times are inclusive milliseconds, so "closed before" is "closed at or before one
+	// millisecond earlier"
+	targetCloseTimes := make([]int64, len(targetSeries))
+	for i, candle := range targetSeries {
+		targetCloseTimes[i] = targetTimeframe.CloseTime(candle.Timestamp) - 1
+	}
+	sourceCloseTimes := make([]int64, len(sourceValues))
+	for i, value := range sourceValues {
+		sourceCloseTimes[i] = sourceTimeframe.CloseTime(value.Timestamp)
+	}
+
+	var joined []FeatureValue
+	params := spec.ParamsString()
+	for i, index := range derivatives.AsOfIndexes(targetCloseTimes, sourceCloseTimes, 2*sourceTimeframe.NominalMillis()) {
+		if index < 0 {
+			continue
+		}
+		joined = append(joined, FeatureValue{
+			Exchange:  targetSeries[i].Exchange,
+			Symbol:    targetSeries[i].Symbol,
+			Timeframe: targetSeries[i].Timeframe,
+			Timestamp: targetSeries[i].Timestamp,
+			Name:      spec.StoredName(),
+			Params:    params,
+			Value:     sourceValues[index].Value,
+		})
+	}
+	return joined, nil
+}
@@ -0,0 +1,73 @@
+package features
+
+import "testing"
+
+func TestJoinCrossTimeframeUsesOnlyClosedSourceBars(t *testing.T) {
+	const day = 24 * 60 * 60 * 1000
+	const march1 = 1709251200000 // 2024-03-01 00:00 UTC
+	// Six 4h bars covering 2024-03-01 (the last one closes at 23:59:59.999, with the 1d bar) and
+	// the first bar of 2024-03-02
+	target := testSeries(closeSeries(1, 2, 3, 4, 5, 6, 7))
+	source := []FeatureValue{
+		{Exchange: "binance", Symbol: "BTCUSDT", Timeframe: "1d", Timestamp: march1 - day, Name: "ema", Params: "3", Value: 100},
+		{Exchange: "binance", Symbol: "BTCUSDT", Timeframe: "1d", Timestamp: march1, Name: "ema", Params: "3", Value: 200},
+	}
+	spec, err := ParseSpec("1d:ema(3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined, err := JoinCrossTimeframe(target, source, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(joined) != len(target) {
+		t.Fatalf("joined %d values, want one per target bar (%d)", len(joined), len(target))
+	}
+	for i, value := range joined {
+		// Every bar of 2024-03-01 sees the previous day, including the one closing together with
+		// the 1d bar; the first bar after it sees the new value
+		want := 100.0
+		if i == len(target)-1 {
+			want = 200
+		}
+		if value.Timestamp != target[i].Timestamp || value.Value != want {
+			t.Errorf("bar %d at %d = %v, want %v", i, target[i].Timestamp, value.Value, want)
+		}
+		if value.Timeframe != "4h" || value.Name != "1d:ema" || value.Params != "3" {
+			t.Errorf("bar %d keyed as %s %s(%s), want 4h 1d:ema(3)", i, value.Timeframe, value.Name, value.Params)
+		}
+	}
+}
+
+func TestJoinCrossTimeframeDropsStaleSourceValues(t *testing.T) {
+	const day = 24 * 60 * 60 * 1000
+	const march1 = 1709251200000
+	// The only 1d value closed three days before the target bars close: more than two source bars old
+	target := testSeries(closeSeries(1))
+	source := []FeatureValue{{Timeframe: "1d", Timestamp: march1 - 3*day, Value: 100}}
+	spec, err := ParseSpec("1d:ema(3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined, err := JoinCrossTimeframe(target, source, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(joined) != 0 {
+		t.Fatalf("joined %+v, want no value from a stale source bar", joined)
+	}
+}
+
+func TestJoinCrossTimeframeRejectsMismatchedSource(t *testing.T) {
+	target := testSeries(closeSeries(1))
+	source := []FeatureValue{{Timeframe: "1h", Timestamp: 1709251200000, Value: 1}}
+	spec, err := ParseSpec("1d:ema(3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := JoinCrossTimeframe(target, source, spec); err == nil {
+		t.Fatal("joined 1h values as 1d, want an error")
+	}
+}
@@ -0,0 +1,298 @@
+package features
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+// Calculator is one streaming feature. It consumes final candles of a single series in time
+// order and returns the value for the latest candle; ok stays false until its lookback is
+// satisfied (or when the value is undefined for that bar).
+type Calculator interface {
+	Update(candle candles.Candle) (value float64, ok bool)
+}
+
+// Definition is a named, parameterised calculator in the registry.
+type Definition struct {
+	Name        string
+	Params      []string  // parameter names, in spec order
+	Defaults    []float64 // defaults for the trailing parameters (may be shorter than Params)
+	Description string
+
+	New func(params []float64) (Calculator, error)
+}
+
+var registry = map[string]Definition{}
+
+// Register adds a calculator to the registry; names must be unique.
+func Register(definition Definition) {
+	if !specNamePattern.MatchString(definition.Name) {
+		panic(fmt.Sprintf("features: invalid feature name %q", definition.Name))
+	}
+	if _, exists := registry[definition.Name]; exists {
+		panic(fmt.Sprintf("features: feature %q registered twice", definition.Name))
+	}
+	if len(definition.Defaults) > len(definition.Params) {
+		panic(fmt.Sprintf("features: feature %q has more defaults than params", definition.Name))
+	}
+	registry[definition.Name] = definition
+}
+
+// Definitions lists the registered calculators by name.
+func Definitions() []Definition {
+	definitions := make([]Definition, 0, len(registry))
+	for _, definition := range registry {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions
+}
+
+// Usage is the spec syntax of a definition, e.g. "ema(period)".
+func (definition Definition) Usage() string {
+	if len(definition.Params) == 0 {
+		return definition.Name
+	}
+	return definition.Name + "(" + strings.Join(definition.Params, ",") + ")"
+}
+
+// Spec selects one registered feature with concrete parameters, written as name(p1,p2,...),
+// e.g. ema(20) or ema_spread(10,30). Parameterless features may drop the parentheses. A
+// timeframe prefix, e.g. 1d:ema(20), computes the feature on another stored timeframe of the
+// same exchange and symbol and joins it without look-ahead (see JoinCrossTimeframe).
+type Spec struct {
+	Timeframe string // source timeframe; empty = the series being built
+	Name      string
+	Params    []float64 // every parameter of the definition, defaults filled in
+}
+
+var specNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ParseSpec parses and checks a spec against the registry.
+func ParseSpec(value string) (Spec, error) {
+	text := strings.TrimSpace(value)
+
+	var timeframe string
+	if colon := strings.IndexByte(text, ':'); colon >= 0 {
+		parsed, err := candles.ParseTimeframe(text[:colon])
+		if err != nil {
+			return Spec{}, fmt.Errorf("feature spec %q: %w", value, err)
+		}
+		timeframe = parsed.String()
+		text = strings.TrimSpace(text[colon+1:])
+	}
+
+	name := text
+	var paramTexts []string
+	if open := strings.IndexByte(text, '('); open >= 0 {
+		if !strings.HasSuffix(text, ")") {
+			return Spec{}, fmt.Errorf("invalid feature spec %q: missing closing parenthesis", value)
+		}
+		name = strings.TrimSpace(text[:open])
+		if inner := strings.TrimSpace(text[open+1 : len(text)-1]); inner != "" {
+			paramTexts = strings.Split(inner, ",")
+		}
+	}
+
+	definition, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return Spec{}, fmt.Errorf("unknown feature %q in spec %q", name, value)
+	}
+	if len(paramTexts) > len(definition.Params) {
+		return Spec{}, fmt.Errorf("feature spec %q: %s takes %d parameter(s)", value, definition.Usage(), len(definition.Params))
+	}
+	firstDefault := len(definition.Params) - len(definition.Defaults)
+	if len(paramTexts) < firstDefault {
+		return Spec{}, fmt.Errorf("feature spec %q: usage %s", value, definition.Usage())
+	}
+
+	spec := Spec{Timeframe: timeframe, Name: definition.Name, Params: make([]float64, len(definition.Params))}
+	for i := range definition.Params {
+		if i >= len(paramTexts) {
+			spec.Params[i] = definition.Defaults[i-firstDefault]
+			continue
+		}
+		param, err := strconv.ParseFloat(strings.TrimSpace(paramTexts[i]), 64)
+		if err != nil || math.IsNaN(param) || math.IsInf(param, 0) {
+			return Spec{}, fmt.Errorf("feature spec %q: invalid %s %q", value, definition.Params[i], paramTexts[i])
+		}
+		spec.Params[i] = param
+	}
+
+	// Fail on bad parameters now rather than mid-build
+	if _, err := definition.New(spec.Params); err != nil {
+		return Spec{}, fmt.Errorf("feature spec %q: %w", value, err)
+	}
+	return spec, nil
+}
+
+// ParseSpecs parses a list of specs, rejecting duplicates.
+func ParseSpecs(values []string) ([]Spec, error) {
+	specs := make([]Spec, 0, len(values))
+	seen := map[string]bool{}
+	for _, value := range values {
+		spec, err := ParseSpec(value)
+		if err != nil {
+			return nil, err
+		}
+		if seen[spec.String()] {
+			return nil, fmt.Errorf("feature %s requested twice", spec)
+		}
+		seen[spec.String()] = true
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// ParamsString is the canonical parameter list stored in feature_values.params, e.g. "10,30".
+func (spec Spec) ParamsString() string {
+	parts := make([]string, len(spec.Params))
+	for i, param := range spec.Params {
+		parts[i] = strconv.FormatFloat(param, 'g', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+// StoredName is the feature_values.name of the spec: the calculator name, prefixed with the
+// source timeframe for cross-timeframe specs (e.g. "1d:ema").
+func (spec Spec) StoredName() string {
+	if spec.Timeframe == "" {
+		return spec.Name
+	}
+	return spec.Timeframe + ":" + spec.Name
+}
+
+// String is the canonical spec, e.g. "ema(20)" or "1d:ema(20)".
+func (spec Spec) String() string {
+	if len(spec.Params) == 0 {
+		return spec.StoredName()
+	}
+	return spec.StoredName() + "(" + spec.ParamsString() + ")"
+}
+
+// Source is the spec as computed on its own timeframe (no prefix).
+func (spec Spec) Source() Spec {
+	spec.Timeframe = ""
+	return spec
+}
+
+// ForSeries drops a timeframe prefix that names the series' own timeframe, so 4h:ema(20) on a
+// 4h series is stored and loaded as ema(20).
+func (spec Spec) ForSeries(timeframe string) Spec {
+	if spec.Timeframe != "" {
+		if parsed, err := candles.ParseTimeframe(timeframe); err == nil && parsed.String() == spec.Timeframe {
+			spec.Timeframe = ""
+		}
+	}
+	return spec
+}
+
+// NewCalculator builds a fresh calculator for the spec.
+func (spec Spec) NewCalculator() (Calculator, error) {
+	definition, ok := registry[spec.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown feature %q", spec.Name)
+	}
+	return definition.New(spec.Params)
+}
+
+// FeatureValue is one registry feature value in the long-format feature_values table.
+type FeatureValue struct {
+	Exchange  string
+	Symbol    string
+	Timeframe string
+	Timestamp int64
+
+	Name   string // Spec.StoredName
+	Params string // Spec.ParamsString
+	Value  float64
+}
+
+// ComputeFeatureValues runs every spec over the final candles of one series. Bars still in a
+// calculator's warm-up produce no value (NULL in the dataset). Cross-timeframe specs run over
+// sources[spec.Timeframe] (candles of the same exchange and symbol) and are joined onto the
+// series with JoinCrossTimeframe.
+func ComputeFeatureValues(candleSeries []candles.Candle, specs []Spec, sources map[string][]candles.Candle) ([]FeatureValue, error) {
+	candleSeries = candles.FinalOnly(candleSeries)
+	if len(candleSeries) == 0 {
+		return nil, nil
+	}
+
+	var values []FeatureValue
+	for _, spec := range specs {
+		spec = spec.ForSeries(candleSeries[0].Timeframe)
+		if spec.Timeframe == "" {
+			seriesValues, err := computeSeriesValues(candleSeries, spec)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, seriesValues...)
+			continue
+		}
+
+		sourceSeries := candles.FinalOnly(sources[spec.Timeframe])
+		sourceValues, err := computeSeriesValues(sourceSeries, spec.Source())
+		if err != nil {
+			return nil, err
+		}
+		joined, err := JoinCrossTimeframe(candleSeries, sourceValues, spec)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, joined...)
+	}
+	return values, nil
+}
+
+// computeSeriesValues runs a fresh calculator over final candles of a single series.
+func computeSeriesValues(candleSeries []candles.Candle, spec Spec) ([]FeatureValue, error) {
+	calculator, err := spec.NewCalculator()
+	if err != nil {
+		return nil, err
+	}
+	return RunCalculator(calculator, candleSeries, spec), nil
+}
+
+// RunCalculator feeds final candles of one series to a calculator (fresh or restored) and
+// returns the values it produced, named after spec.
+func RunCalculator(calculator Calculator, candleSeries []candles.Candle, spec Spec) []FeatureValue {
+	var values []FeatureValue
+	params := spec.ParamsString()
+	for _, candle := range candleSeries {
+		value, ok := calculator.Update(candle)
+		if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		values = append(values, FeatureValue{
+			Exchange:  candle.Exchange,
+			Symbol:    candle.Symbol,
+			Timeframe: candle.Timeframe,
+			Timestamp: candle.Timestamp,
+			Name:      spec.StoredName(),
+			Params:    params,
+			Value:     value,
+		})
+	}
+	return values
+}
+
+// periodParam checks that a parameter is a whole number of bars >= minimum.
+func periodParam(name string, value float64, minimum int) (int, error) {
+	if value != math.Trunc(value) || value < float64(minimum) {
+		return 0, fmt.Errorf("%s must be a whole number >= %d (got %s)", name, minimum, strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	return int(value), nil
+}
+
+func positiveParam(name string, value float64) (float64, error) {
+	if value <= 0 {
+		return 0, fmt.Errorf("%s must be > 0 (got %s)", name, strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	return value, nil
+}
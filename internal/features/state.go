@@ -0,0 +1,28 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Registry calculators keep their streaming state (EMA values, rolling windows, previous bar)
+// in exported fields, so it round-trips exactly through JSON: float64 values are encoded with
+// the shortest representation that parses back to the same bits.
+
+// MarshalCalculator returns the JSON state of a calculator.
+func MarshalCalculator(calculator Calculator) ([]byte, error) {
+	return json.Marshal(calculator)
+}
+
+// RestoreCalculator builds the spec's calculator and loads state saved by MarshalCalculator
+// from a calculator of the same spec.
+func (spec Spec) RestoreCalculator(state []byte) (Calculator, error) {
+	calculator, err := spec.NewCalculator()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(state, calculator); err != nil {
+		return nil, fmt.Errorf("restore %s state: %w", spec, err)
+	}
+	return calculator, nil
+}
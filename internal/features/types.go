@@ -15,4 +15,8 @@ type FeatureRow struct {
 	RangeHL   *float64
 	RangeCO   *float64
 	VolChg    *float64
+
+	// Perpetual futures context, as of the bar open (nil where history is missing)
+	FundingRate *float64
+	OIChg       *float64
 }
@@ -0,0 +1,191 @@
+package features
+
+import (
+	"math"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+// Range-based volatility estimators over the stored OHLC, per bar (not annualised), plus
+// regime features built on close-to-close vol. Estimator definitions follow Parkinson (1980),
+// Garman & Klass (1980), Rogers & Satchell (1991) and Yang & Zhang (2000).
+func init() {
+	for _, estimator := range []string{parkinsonVolatility, garmanKlassVolatility, rogersSatchellVolatility} {
+		Register(Definition{
+			Name:        estimator,
+			Params:      []string{"period"},
+			Defaults:    []float64{20},
+			Description: rangeVolatilityDescriptions[estimator],
+			New: func(params []float64) (Calculator, error) {
+				period, err := periodParam("period", params[0], 1)
+				if err != nil {
+					return nil, err
+				}
+				return &rangeVolatilityCalculator{Estimator: estimator, Terms: newRollingWindow(period)}, nil
+			},
+		})
+	}
+	Register(Definition{
+		Name:        "vol_yz",
+		Params:      []string{"period"},
+		Defaults:    []float64{20},
+		Description: "Yang-Zhang: overnight + k*open-to-close + (1-k)*Rogers-Satchell variance",
+		New: func(params []float64) (Calculator, error) {
+			period, err := periodParam("period", params[0], 2)
+			if err != nil {
+				return nil, err
+			}
+			return &yangZhangCalculator{
+				Overnight:   newRollingWindow(period),
+				OpenToClose: newRollingWindow(period),
+				RogersTerms: newRollingWindow(period),
+			}, nil
+		},
+	})
+	Register(Definition{
+		Name:        "vol_of_vol",
+		Params:      []string{"period", "vol_period"},
+		Defaults:    []float64{20, 20},
+		Description: "Population standard deviation of the last period vol(vol_period) values",
+		New: func(params []float64) (Calculator, error) {
+			period, err := periodParam("period", params[0], 2)
+			if err != nil {
+				return nil, err
+			}
+			volPeriod, err := periodParam("vol_period", params[1], 2)
+			if err != nil {
+				return nil, err
+			}
+			return &volOfVolCalculator{Vol: volCalculator{Returns: newRollingWindow(volPeriod)}, Values: newRollingWindow(period)}, nil
+		},
+	})
+	Register(Definition{
+		Name:        "vol_ratio",
+		Params:      []string{"short", "long"},
+		Defaults:    []float64{10, 50},
+		Description: "vol(short) / vol(long); above 1 when recent dispersion exceeds the longer regime",
+		New: func(params []float64) (Calculator, error) {
+			short, err := periodParam("short", params[0], 2)
+			if err != nil {
+				return nil, err
+			}
+			long, err := periodParam("long", params[1], 2)
+			if err != nil {
+				return nil, err
+			}
+			return &volRatioCalculator{
+				Short: volCalculator{Returns: newRollingWindow(short)},
+				Long:  volCalculator{Returns: newRollingWindow(long)},
+			}, nil
+		},
+	})
+}
+
+const (
+	parkinsonVolatility      = "vol_parkinson"
+	garmanKlassVolatility    = "vol_gk"
+	rogersSatchellVolatility = "vol_rs"
+)
+
+var rangeVolatilityDescriptions = map[string]string{
+	parkinsonVolatility:      "Parkinson: sqrt(mean(ln(H/L)^2) / (4 ln 2))",
+	garmanKlassVolatility:    "Garman-Klass: sqrt(mean(0.5 ln(H/L)^2 - (2 ln 2 - 1) ln(C/O)^2))",
+	rogersSatchellVolatility: "Rogers-Satchell: sqrt(mean(ln(H/C) ln(H/O) + ln(L/C) ln(L/O)))",
+}
+
+// rogersSatchellTerm is drift-independent: it is zero for bars that only move one way.
+func rogersSatchellTerm(candle candles.Candle) float64 {
+	return math.Log(candle.High/candle.Close)*math.Log(candle.High/candle.Open) +
+		math.Log(candle.Low/candle.Close)*math.Log(candle.Low/candle.Open)
+}
+
+// rangeVolatilityCalculator averages a per-bar variance term over the window.
+type rangeVolatilityCalculator struct {
+	Estimator string        `json:"estimator"`
+	Terms     rollingWindow `json:"terms"`
+}
+
+func (calculator *rangeVolatilityCalculator) Update(candle candles.Candle) (float64, bool) {
+	highLow := math.Log(candle.High / candle.Low)
+	var term float64
+	switch calculator.Estimator {
+	case parkinsonVolatility:
+		term = highLow * highLow / (4 * math.Ln2)
+	case garmanKlassVolatility:
+		closeOpen := math.Log(candle.Close / candle.Open)
+		term = 0.5*highLow*highLow - (2*math.Ln2-1)*closeOpen*closeOpen
+	default:
+		term = rogersSatchellTerm(candle)
+	}
+
+	calculator.Terms.Push(term)
+	if !calculator.Terms.Full() {
+		return 0, false
+	}
+	variance := calculator.Terms.Mean()
+	if variance < 0 {
+		return 0, false
+	}
+	return math.Sqrt(variance), true
+}
+
+type yangZhangCalculator struct {
+	Change      closeChange   `json:"change"`
+	Overnight   rollingWindow `json:"overnight"`     // ln(open / previous close)
+	OpenToClose rollingWindow `json:"open_to_close"` // ln(close / open)
+	RogersTerms rollingWindow `json:"rogers_terms"`
+}
+
+func (calculator *yangZhangCalculator) Update(candle candles.Candle) (float64, bool) {
+	previousClose, ok := calculator.Change.advance(candle.Close)
+	if !ok {
+		return 0, false
+	}
+	calculator.Overnight.Push(math.Log(candle.Open / previousClose))
+	calculator.OpenToClose.Push(math.Log(candle.Close / candle.Open))
+	calculator.RogersTerms.Push(rogersSatchellTerm(candle))
+	if !calculator.Overnight.Full() {
+		return 0, false
+	}
+
+	n := float64(calculator.Overnight.Size)
+	k := 0.34 / (1.34 + (n+1)/(n-1))
+	variance := calculator.Overnight.SampleVariance() +
+		k*calculator.OpenToClose.SampleVariance() +
+		(1-k)*calculator.RogersTerms.Mean()
+	if variance < 0 {
+		return 0, false
+	}
+	return math.Sqrt(variance), true
+}
+
+type volOfVolCalculator struct {
+	Vol    volCalculator `json:"vol"`
+	Values rollingWindow `json:"values"`
+}
+
+func (calculator *volOfVolCalculator) Update(candle candles.Candle) (float64, bool) {
+	vol, ok := calculator.Vol.Update(candle)
+	if !ok {
+		return 0, false
+	}
+	calculator.Values.Push(vol)
+	if !calculator.Values.Full() {
+		return 0, false
+	}
+	return calculator.Values.Std(), true
+}
+
+type volRatioCalculator struct {
+	Short volCalculator `json:"short"`
+	Long  volCalculator `json:"long"`
+}
+
+func (calculator *volRatioCalculator) Update(candle candles.Candle) (float64, bool) {
+	short, shortOK := calculator.Short.Update(candle)
+	long, longOK := calculator.Long.Update(candle)
+	if !shortOK || !longOK || long == 0 {
+		return 0, false
+	}
+	return short / long, true
+}
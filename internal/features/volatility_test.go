@@ -0,0 +1,53 @@
+package features
+
+import (
+	"math"
+	"testing"
+)
+
+// price places a level at a known log-distance from 100 so every ln(a/b) in the
+// estimators is a round number.
+func price(logReturn float64) float64 {
+	return 100 * math.Exp(logReturn)
+}
+
+func TestVolatilityKnownAnswers(t *testing.T) {
+	// ln(H/L) = 0.06, ln(C/O) = 0.01, ln(H/C) = 0.03, ln(H/O) = 0.04, ln(L/C) = -0.03, ln(L/O) = -0.02
+	wide := bar{open: price(0), high: price(0.04), low: price(-0.02), close: price(0.01), volume: 1}
+	// ln(H/L) = 0.02
+	narrow := bar{open: price(0), high: price(0.01), low: price(-0.01), close: price(0), volume: 1}
+	// Opens on the low and closes on the high
+	oneWay := bar{open: price(0), high: price(0.03), low: price(0), close: price(0.03), volume: 1}
+	// Close outside the high-low range, which makes the Garman-Klass term negative
+	outside := bar{open: price(0), high: price(0), low: price(0), close: price(0.01), volume: 1}
+
+	// Yang-Zhang(2): overnight 0.01, -0.01 (sample variance 0.0002), open-to-close 0.02, -0.01
+	// (sample variance 0.00045) and Rogers-Satchell terms 0 and 0.03*0.02 + 0.01*0.02 = 0.0008
+	yangZhang := []bar{
+		{open: price(0), high: price(0), low: price(0), close: price(0), volume: 1},
+		{open: price(0.01), high: price(0.03), low: price(0.01), close: price(0.03), volume: 1},
+		{open: price(0.02), high: price(0.04), low: price(0), close: price(0.01), volume: 1},
+	}
+	k := 0.34 / (1.34 + 3)
+	// Overnight and open-to-close are constant (zero variance) while both bars close above
+	// their high, so the Rogers-Satchell mean, and the whole variance, is -0.02
+	negativeYangZhang := []bar{
+		{open: price(0), high: price(0), low: price(0), close: price(0), volume: 1},
+		{open: price(-0.1), high: price(0), low: price(0), close: price(0.1), volume: 1},
+		{open: price(0), high: price(0.1), low: price(0.1), close: price(0.2), volume: 1},
+	}
+
+	checkKnownAnswers(t, []knownAnswer{
+		{"parkinson", "vol_parkinson(1)", []bar{wide}, []float64{0.06 / (2 * math.Sqrt(math.Ln2))}},
+		{"parkinson window", "vol_parkinson(2)", []bar{wide, narrow}, []float64{none, math.Sqrt((0.0036 + 0.0004) / 2 / (4 * math.Ln2))}},
+
+		{"garman-klass", "vol_gk(1)", []bar{wide}, []float64{math.Sqrt(0.5*0.0036 - (2*math.Ln2-1)*0.0001)}},
+		{"garman-klass negative variance", "vol_gk(1)", []bar{outside, wide}, []float64{none, math.Sqrt(0.5*0.0036 - (2*math.Ln2-1)*0.0001)}},
+
+		{"rogers-satchell", "vol_rs(1)", []bar{wide}, []float64{math.Sqrt(0.03*0.04 + 0.03*0.02)}},
+		{"rogers-satchell one-way bar", "vol_rs(1)", []bar{oneWay}, []float64{0}},
+
+		{"yang-zhang", "vol_yz(2)", yangZhang, []float64{none, none, math.Sqrt(0.0002 + k*0.00045 + (1-k)*0.0004)}},
+		{"yang-zhang negative variance", "vol_yz(2)", negativeYangZhang, []float64{none, none, none}},
+	})
+}
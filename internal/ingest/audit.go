@@ -0,0 +1,248 @@
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/store"
+)
+
+type Severity int
+
+const (
+	SeverityInfo Severity = iota + 1
+	SeverityWarning
+	SeverityError
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "none"
+	}
+}
+
+// MarshalText makes severities appear by name in JSON reports.
+func (severity Severity) MarshalText() ([]byte, error) {
+	return []byte(severity.String()), nil
+}
+
+// ParseSeverity accepts info, warning or error; "none" (or empty) parses to 0, below every issue.
+func ParseSeverity(value string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "none":
+		return 0, nil
+	case "info":
+		return SeverityInfo, nil
+	case "warning", "warn":
+		return SeverityWarning, nil
+	case "error":
+		return SeverityError, nil
+	default:
+		return 0, fmt.Errorf("unknown severity %q (none, info, warning, error)", value)
+	}
+}
+
+// Audit check names, as reported in Issue.Check.
+const (
+	CheckGap             = "gap"
+	CheckMisaligned      = "misaligned_timestamp"
+	CheckCloseTime       = "close_time_mismatch"
+	CheckNonFinalHistory = "non_final_in_history"
+	CheckZeroVolume      = "zero_volume"
+	CheckFlatBar         = "flat_ohlc"
+	CheckPriceJump       = "price_jump"
+)
+
+// AuditChecks lists every check in report order.
+var AuditChecks = []string{
+	CheckGap,
+	CheckMisaligned,
+	CheckCloseTime,
+	CheckNonFinalHistory,
+	CheckZeroVolume,
+	CheckFlatBar,
+	CheckPriceJump,
+}
+
+type Issue struct {
+	Check     string   `json:"check"`
+	Severity  Severity `json:"severity"`
+	Timestamp int64    `json:"timestamp"` // open time of the offending candle (for gaps, the first missing one)
+	Detail    string   `json:"detail"`
+}
+
+type AuditConfig struct {
+	// A close-to-close log return beyond JumpSigmas rolling standard deviations of the previous
+	// JumpWindow returns is flagged as a price jump
+	JumpSigmas float64
+	JumpWindow int
+}
+
+func DefaultAuditConfig() AuditConfig {
+	return AuditConfig{JumpSigmas: 8, JumpWindow: 50}
+}
+
+type AuditReport struct {
+	Validation ValidationResult `json:"validation"`
+	Issues     []Issue          `json:"issues"`
+}
+
+// MaxSeverity is the highest severity among the issues, 0 when there are none.
+func (report AuditReport) MaxSeverity() Severity {
+	var max Severity
+	for _, issue := range report.Issues {
+		if issue.Severity > max {
+			max = issue.Severity
+		}
+	}
+	return max
+}
+
+// CountAtLeast counts issues at or above severity.
+func (report AuditReport) CountAtLeast(severity Severity) int {
+	count := 0
+	for _, issue := range report.Issues {
+		if issue.Severity >= severity {
+			count++
+		}
+	}
+	return count
+}
+
+// CountByCheck counts issues per check name; every check has an entry, 0 when clean.
+func (report AuditReport) CountByCheck() map[string]int {
+	counts := map[string]int{}
+	for _, check := range AuditChecks {
+		counts[check] = 0
+	}
+	for _, issue := range report.Issues {
+		counts[issue.Check]++
+	}
+	return counts
+}
+
+// AuditCandles runs ValidateCandleContinuity plus per-candle quality checks over every stored
+// candle of the series (non-final ones included) and returns the issues in time order.
+// Severities: structural problems that corrupt features are errors; suspicious but possibly
+// genuine market data is a warning; cosmetic findings are info.
+func AuditCandles(
+	ctx context.Context,
+	db *sql.DB,
+	exchange string,
+	symbol string,
+	timeframe candles.Timeframe,
+	config AuditConfig,
+) (AuditReport, error) {
+	validation, err := ValidateCandleContinuity(ctx, db, exchange, symbol, timeframe)
+	if err != nil {
+		return AuditReport{}, err
+	}
+	candleSeries, err := store.LoadAllCandlesOrdered(ctx, db, exchange, symbol, timeframe.String())
+	if err != nil {
+		return AuditReport{}, err
+	}
+
+	report := AuditReport{Validation: validation, Issues: []Issue{}}
+	add := func(check string, severity Severity, timestamp int64, format string, args ...any) {
+		report.Issues = append(report.Issues, Issue{
+			Check:     check,
+			Severity:  severity,
+			Timestamp: timestamp,
+			Detail:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, gap := range validation.Gaps {
+		// Misaligned gaps show up as misaligned candles below
+		if gap.Missing > 0 {
+			add(CheckGap, SeverityWarning, gap.ExpectedTS, "%d missing candle(s) before %d", gap.Missing, gap.ActualTS)
+		}
+	}
+
+	for i, candle := range candleSeries {
+		if !timeframe.IsAligned(candle.Timestamp) {
+			add(CheckMisaligned, SeverityError, candle.Timestamp, "open time is not on a %s boundary (nearest %d)", timeframe, timeframe.Floor(candle.Timestamp))
+		}
+		if expected := timeframe.CloseTime(timeframe.Floor(candle.Timestamp)); candle.CloseTime != 0 && candle.CloseTime != expected {
+			add(CheckCloseTime, SeverityError, candle.Timestamp, "close_time=%d, expected %d", candle.CloseTime, expected)
+		}
+		if !candle.IsFinal && i < len(candleSeries)-1 {
+			add(CheckNonFinalHistory, SeverityError, candle.Timestamp, "non-final candle followed by %d newer candle(s)", len(candleSeries)-1-i)
+		}
+		if candle.Volume == 0 {
+			add(CheckZeroVolume, SeverityWarning, candle.Timestamp, "volume is 0")
+		}
+		if candle.Open == candle.High && candle.High == candle.Low && candle.Low == candle.Close {
+			add(CheckFlatBar, SeverityInfo, candle.Timestamp, "open=high=low=close=%g", candle.Close)
+		}
+	}
+
+	for _, jump := range findPriceJumps(candleSeries, timeframe, config) {
+		add(CheckPriceJump, SeverityWarning, jump.timestamp, "log return %.4f is %.1f sigma (rolling %d)", jump.logReturn, jump.sigmas, config.JumpWindow)
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool { return report.Issues[i].Timestamp < report.Issues[j].Timestamp })
+	return report, nil
+}
+
+type priceJump struct {
+	timestamp int64
+	logReturn float64
+	sigmas    float64
+}
+
+// findPriceJumps compares each close-to-close log return with the standard deviation of the
+// JumpWindow returns before it. Returns across a gap are skipped; they span more than one bar.
+func findPriceJumps(candleSeries []candles.Candle, timeframe candles.Timeframe, config AuditConfig) []priceJump {
+	if config.JumpWindow < 2 || config.JumpSigmas <= 0 {
+		return nil
+	}
+
+	var jumps []priceJump
+	var window []float64
+	for i := 1; i < len(candleSeries); i++ {
+		previous, current := candleSeries[i-1], candleSeries[i]
+		if current.Timestamp != timeframe.Next(previous.Timestamp) || previous.Close <= 0 || current.Close <= 0 {
+			continue
+		}
+		logReturn := math.Log(current.Close / previous.Close)
+
+		if len(window) == config.JumpWindow {
+			if std := standardDeviation(window); std > 0 {
+				if sigmas := math.Abs(logReturn) / std; sigmas > config.JumpSigmas {
+					jumps = append(jumps, priceJump{timestamp: current.Timestamp, logReturn: logReturn, sigmas: sigmas})
+				}
+			}
+			window = window[1:]
+		}
+		window = append(window, logReturn)
+	}
+	return jumps
+}
+
+func standardDeviation(values []float64) float64 {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+
+	var varianceSum float64
+	for _, value := range values {
+		diff := value - mean
+		varianceSum += diff * diff
+	}
+	return math.Sqrt(varianceSum / float64(len(values)))
+}
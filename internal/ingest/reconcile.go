@@ -0,0 +1,133 @@
+package ingest
+
+import (
+	"math"
+	"sort"
+
+	"btc-4h-prediction-model/internal/candles"
+)
+
+type Divergence struct {
+	Timestamp  int64
+	LeftClose  float64
+	RightClose float64
+	RelDiff    float64 // |left-right| / mid
+}
+
+type ReconcileResult struct {
+	Matched   int
+	LeftOnly  int
+	RightOnly int
+
+	// Relative close difference over matched bars
+	MeanCloseRelDiff   float64
+	MedianCloseRelDiff float64
+	MaxCloseRelDiff    float64
+	MaxCloseRelDiffTS  int64
+
+	// Volumes differ by venue size, so compare their level ratio and how they co-move
+	MedianVolumeRatio float64 // left/right over bars where both traded
+	VolumeCorrelation float64 // Pearson correlation of log volumes; NaN with < 2 bars
+
+	// Matched bars whose relative close difference exceeds the threshold, in time order
+	Divergences []Divergence
+}
+
+// ReconcileCandles aligns two ordered series on open time and compares them bar by bar. A bar
+// diverges when |left.Close-right.Close| / mid-price exceeds threshold.
+func ReconcileCandles(left []candles.Candle, right []candles.Candle, threshold float64) ReconcileResult {
+	var result ReconcileResult
+	var closeRelDiffs, volumeRatios, leftLogVolumes, rightLogVolumes []float64
+
+	i, j := 0, 0
+	for i < len(left) || j < len(right) {
+		switch {
+		case j >= len(right) || (i < len(left) && left[i].Timestamp < right[j].Timestamp):
+			result.LeftOnly++
+			i++
+			continue
+		case i >= len(left) || right[j].Timestamp < left[i].Timestamp:
+			result.RightOnly++
+			j++
+			continue
+		}
+
+		leftCandle, rightCandle := left[i], right[j]
+		i++
+		j++
+		result.Matched++
+
+		mid := (leftCandle.Close + rightCandle.Close) / 2
+		if mid <= 0 {
+			continue
+		}
+		relDiff := math.Abs(leftCandle.Close-rightCandle.Close) / mid
+		closeRelDiffs = append(closeRelDiffs, relDiff)
+		if relDiff > result.MaxCloseRelDiff {
+			result.MaxCloseRelDiff = relDiff
+			result.MaxCloseRelDiffTS = leftCandle.Timestamp
+		}
+		if relDiff > threshold {
+			result.Divergences = append(result.Divergences, Divergence{
+				Timestamp:  leftCandle.Timestamp,
+				LeftClose:  leftCandle.Close,
+				RightClose: rightCandle.Close,
+				RelDiff:    relDiff,
+			})
+		}
+
+		if leftCandle.Volume > 0 && rightCandle.Volume > 0 {
+			volumeRatios = append(volumeRatios, leftCandle.Volume/rightCandle.Volume)
+			leftLogVolumes = append(leftLogVolumes, math.Log(leftCandle.Volume))
+			rightLogVolumes = append(rightLogVolumes, math.Log(rightCandle.Volume))
+		}
+	}
+
+	result.MeanCloseRelDiff = mean(closeRelDiffs)
+	result.MedianCloseRelDiff = median(closeRelDiffs)
+	result.MedianVolumeRatio = median(volumeRatios)
+	result.VolumeCorrelation = correlation(leftLogVolumes, rightLogVolumes)
+	return result
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+func correlation(x []float64, y []float64) float64 {
+	if len(x) < 2 || len(x) != len(y) {
+		return math.NaN()
+	}
+	meanX, meanY := mean(x), mean(y)
+	var covariance, varianceX, varianceY float64
+	for k := range x {
+		dx, dy := x[k]-meanX, y[k]-meanY
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+	if varianceX == 0 || varianceY == 0 {
+		return math.NaN()
+	}
+	return covariance / math.Sqrt(varianceX*varianceY)
+}
@@ -0,0 +1,108 @@
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/exchange"
+	"btc-4h-prediction-model/internal/store"
+)
+
+const unfillableGapReason = "exchange returned no candles for the range"
+
+type RepairResult struct {
+	// Gaps reported by validation before the repair
+	GapsFound int
+	// Candles upserted by refetching the missing ranges
+	Upserted int
+
+	// Gaps the exchange could not fill; recorded in known_gaps when requested
+	Unfillable []Gap
+	// Misaligned timestamps, which refetching cannot fix
+	Misaligned []Gap
+}
+
+// RefetchGaps fetches exactly the missing range of every aligned gap and upserts what the
+// source returns. Returns the number of candles upserted.
+func RefetchGaps(
+	ctx context.Context,
+	db *sql.DB,
+	source exchange.CandleSource,
+	symbol string,
+	timeframe candles.Timeframe,
+	gaps []Gap,
+) (int, error) {
+	upserted := 0
+	for _, gap := range gaps {
+		// Misaligned timestamps cannot be repaired by refetching
+		if gap.Missing <= 0 {
+			continue
+		}
+
+		gapCandles, err := source.FetchCandles(ctx, symbol, timeframe.String(), gap.ExpectedTS, gap.ActualTS-1)
+		if err != nil {
+			return upserted, err
+		}
+		if err := store.UpsertCandles(ctx, db, gapCandles); err != nil {
+			return upserted, err
+		}
+		upserted += len(gapCandles)
+	}
+	return upserted, nil
+}
+
+// RepairGaps validates the series, refetches its gaps and validates again. Gaps that survive the
+// refetch are ones the exchange genuinely has no data for; with recordKnown they are written to
+// known_gaps so later validation stops flagging them.
+func RepairGaps(
+	ctx context.Context,
+	db *sql.DB,
+	source exchange.CandleSource,
+	symbol string,
+	timeframe candles.Timeframe,
+	recordKnown bool,
+) (RepairResult, error) {
+	before, err := ValidateCandleContinuity(ctx, db, source.Name(), symbol, timeframe)
+	if err != nil {
+		return RepairResult{}, err
+	}
+
+	result := RepairResult{GapsFound: len(before.Gaps)}
+	result.Upserted, err = RefetchGaps(ctx, db, source, symbol, timeframe, before.Gaps)
+	if err != nil {
+		return result, err
+	}
+
+	after, err := ValidateCandleContinuity(ctx, db, source.Name(), symbol, timeframe)
+	if err != nil {
+		return result, err
+	}
+
+	var knownGaps []store.KnownGap
+	recordedAt := time.Now().Unix()
+	for _, gap := range after.Gaps {
+		if gap.Missing <= 0 {
+			result.Misaligned = append(result.Misaligned, gap)
+			continue
+		}
+		result.Unfillable = append(result.Unfillable, gap)
+		knownGaps = append(knownGaps, store.KnownGap{
+			Exchange:   source.Name(),
+			Symbol:     symbol,
+			Timeframe:  timeframe.String(),
+			StartTS:    gap.ExpectedTS,
+			EndTS:      gap.ActualTS,
+			Reason:     unfillableGapReason,
+			RecordedAt: recordedAt,
+		})
+	}
+
+	if recordKnown {
+		if err := store.UpsertKnownGaps(ctx, db, knownGaps); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -4,37 +4,44 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+
+	"btc-4h-prediction-model/internal/candles"
+	"btc-4h-prediction-model/internal/store"
 )
 
 type Gap struct {
-	PreviousTS int64
-	ExpectedTS int64
-	ActualTS   int64
-	Missing    int64 // number of missing intervals
+	PreviousTS int64 `json:"previous_ts"`
+	ExpectedTS int64 `json:"expected_ts"`
+	ActualTS   int64 `json:"actual_ts"`
+	Missing    int64 `json:"missing"` // number of missing intervals
 }
 
 type ValidationResult struct {
-	Exchange  string
-	Symbol    string
-	Timeframe string
+	Exchange  string `json:"exchange"`
+	Symbol    string `json:"symbol"`
+	Timeframe string `json:"timeframe"`
+
+	Count int64 `json:"count"`
 
-	Count int64
+	FirstTS int64 `json:"first_ts"`
+	LastTS  int64 `json:"last_ts"`
 
-	FirstTS int64
-	LastTS  int64
+	Gaps []Gap `json:"gaps"`
 
-	Gaps []Gap
+	// Gaps covered by a known_gaps entry; reported separately and not counted as problems
+	KnownGaps []Gap `json:"known_gaps"`
 }
 
-// ValidateCandleContinuity checks that ts is strictly increasing and spaced by expectedIntervalMillis.
-// It reports gaps (missing candles). Duplicates are prevented by PK, but this still detects query/order issues.
+// ValidateCandleContinuity checks that ts is strictly increasing and that each candle opens at the
+// timeframe's next bar boundary after the previous one (calendar-aware for 1w/1M).
+// It reports gaps (missing candles); gaps recorded in known_gaps go to KnownGaps instead.
+// Duplicates are prevented by PK, but this still detects query/order issues.
 func ValidateCandleContinuity(
 	ctx context.Context,
 	db *sql.DB,
 	exchange string,
 	symbol string,
-	timeframe string,
-	expectedIntervalMillis int64,
+	timeframe candles.Timeframe,
 ) (ValidationResult, error) {
 
 	rows, err := db.QueryContext(ctx, `
@@ -42,7 +49,7 @@ SELECT timestamp
 FROM candles
 WHERE exchange = ? AND symbol = ? AND timeframe = ?
 ORDER BY timestamp ASC;
-`, exchange, symbol, timeframe)
+`, exchange, symbol, timeframe.String())
 	if err != nil {
 		return ValidationResult{}, err
 	}
@@ -51,7 +58,10 @@ ORDER BY timestamp ASC;
 	var result ValidationResult
 	result.Exchange = exchange
 	result.Symbol = symbol
-	result.Timeframe = timeframe
+	result.Timeframe = timeframe.String()
+	// Empty lists rather than nil so JSON reports always carry arrays
+	result.Gaps = []Gap{}
+	result.KnownGaps = []Gap{}
 
 	var previousTS int64
 	havePrevious := false
@@ -75,17 +85,16 @@ ORDER BY timestamp ASC;
 				return ValidationResult{}, fmt.Errorf("non-increasing ts detected: prev=%d current=%d", previousTS, currentTS)
 			}
 
-			expectedTS := previousTS + expectedIntervalMillis
+			expectedTS := timeframe.Next(previousTS)
 			if currentTS != expectedTS {
 				// Compute how many intervals are missing (could be >1 if large gap)
-				delta := currentTS - previousTS
-				if delta > expectedIntervalMillis && delta%expectedIntervalMillis == 0 {
-					missingIntervals := (delta / expectedIntervalMillis) - 1
+				steps, aligned := timeframe.BarsBetween(previousTS, currentTS)
+				if aligned && steps > 1 {
 					result.Gaps = append(result.Gaps, Gap{
 						PreviousTS: previousTS,
 						ExpectedTS: expectedTS,
 						ActualTS:   currentTS,
-						Missing:    missingIntervals,
+						Missing:    steps - 1,
 					})
 				} else {
 					// Not aligned to interval boundary (weird data)
@@ -107,5 +116,35 @@ ORDER BY timestamp ASC;
 		return ValidationResult{}, err
 	}
 
+	knownGaps, err := store.LoadKnownGaps(ctx, db, exchange, symbol, timeframe.String())
+	if err != nil {
+		return ValidationResult{}, err
+	}
+	if len(knownGaps) > 0 {
+		openGaps := result.Gaps[:0]
+		for _, gap := range result.Gaps {
+			if isKnownGap(gap, knownGaps) {
+				result.KnownGaps = append(result.KnownGaps, gap)
+			} else {
+				openGaps = append(openGaps, gap)
+			}
+		}
+		result.Gaps = openGaps
+	}
+
 	return result, nil
 }
+
+// isKnownGap reports whether a recorded known gap covers the whole missing range of gap.
+// Misaligned gaps are data errors, never known gaps.
+func isKnownGap(gap Gap, knownGaps []store.KnownGap) bool {
+	if gap.Missing <= 0 {
+		return false
+	}
+	for _, known := range knownGaps {
+		if known.StartTS <= gap.ExpectedTS && gap.ActualTS <= known.EndTS {
+			return true
+		}
+	}
+	return false
+}
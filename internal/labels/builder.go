@@ -15,6 +15,9 @@ func BuildForwardReturnLabels(
 	if thresholdB <= 0 {
 		return nil, fmt.Errorf("thresholdB must be > 0")
 	}
+	// A partial next bar would produce a fake forward return
+	candleSeries = candles.FinalOnly(candleSeries)
+
 	if len(candleSeries) < 2 {
 		return nil, fmt.Errorf("need at least 2 candles to build labels")
 	}
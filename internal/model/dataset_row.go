@@ -45,5 +45,8 @@ type DatasetRow struct {
 	RangeCO   float64
 	VolChg    float64
 
+	// Registry features requested for training (train --feature), in request order
+	Extra []float64
+
 	Label Class
 }
@@ -53,3 +53,32 @@ ORDER BY timestamp ASC;
 	}
 	return result, nil
 }
+
+// AttachExtraFeatures appends one value per column to each row's Extra, in column order.
+// Columns map bar timestamp to value; rows missing any column are dropped, like rows with a
+// NULL base feature in the dataset view.
+func AttachExtraFeatures(rows []DatasetRow, columns []map[int64]float64) []DatasetRow {
+	if len(columns) == 0 {
+		return rows
+	}
+
+	result := make([]DatasetRow, 0, len(rows))
+	for _, row := range rows {
+		extra := make([]float64, 0, len(row.Extra)+len(columns))
+		extra = append(extra, row.Extra...)
+		complete := true
+		for _, column := range columns {
+			value, ok := column[row.Timestamp]
+			if !ok {
+				complete = false
+				break
+			}
+			extra = append(extra, value)
+		}
+		if complete {
+			row.Extra = extra
+			result = append(result, row)
+		}
+	}
+	return result
+}
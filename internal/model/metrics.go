@@ -61,6 +61,11 @@ func precisionRecallForClass(cm ConfusionMatrix, class Class) (precision float64
 	return
 }
 
+// PrecisionRecall returns precision and recall of one class (0 when undefined).
+func (cm ConfusionMatrix) PrecisionRecall(class Class) (precision float64, recall float64) {
+	return precisionRecallForClass(cm, class)
+}
+
 func (cm ConfusionMatrix) SummaryString() string {
 	upP, upR := precisionRecallForClass(cm, ClassUp)
 	downP, downR := precisionRecallForClass(cm, ClassDown)
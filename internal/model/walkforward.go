@@ -23,8 +23,15 @@ type WalkForwardResult struct {
 	LogRegPredictions []PredictionRow
 }
 
+// baseFeatureCount is the fixed dataset-view columns; registry features follow them.
+const baseFeatureCount = 7
+
+func featureCount(rows []DatasetRow) int {
+	return baseFeatureCount + len(rows[0].Extra)
+}
+
 func rowsToMatrix(rows []DatasetRow) (*mat.Dense, []Class) {
-	X := mat.NewDense(len(rows), 7, nil) // 7 features
+	X := mat.NewDense(len(rows), featureCount(rows), nil)
 	y := make([]Class, len(rows))
 
 	for i, r := range rows {
@@ -35,6 +42,9 @@ func rowsToMatrix(rows []DatasetRow) (*mat.Dense, []Class) {
 		X.Set(i, 4, r.RangeHL)
 		X.Set(i, 5, r.RangeCO)
 		X.Set(i, 6, r.VolChg)
+		for j, value := range r.Extra {
+			X.Set(i, baseFeatureCount+j, value)
+		}
 		y[i] = r.Label
 	}
 	return X, y
@@ -95,7 +105,7 @@ func EvaluateWalkForward(dataset []DatasetRow, config TrainConfig) (WalkForwardR
 		standardizer.TransformInPlace(Xtrain)
 		standardizer.TransformInPlace(Xtest)
 
-		model := NewSoftmaxLogReg(3, 7)
+		model := NewSoftmaxLogReg(3, featureCount(trainRows))
 		if err := model.FitGradientDescent(Xtrain, ytrain, config.LearningRate, config.L2Lambda, config.Epochs); err != nil {
 			return WalkForwardResult{}, err
 		}
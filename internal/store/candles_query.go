@@ -7,15 +7,54 @@ import (
 	"btc-4h-prediction-model/internal/candles"
 )
 
+// LoadCandlesOrdered returns the closed (is_final=1) candles of a series in time order.
+// The still-forming bar is excluded so downstream features and labels never see a partial candle.
 func LoadCandlesOrdered(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string) ([]candles.Candle, error) {
-	rows, err := db.QueryContext(ctx, `
+	return queryCandles(ctx, db, `
+SELECT exchange, symbol, timeframe, timestamp,
+       open, high, low, close, volume,
+       close_time, is_final,
+       quote_volume, trades, taker_buy_base_volume, taker_buy_quote_volume,
+       resampled_from
+FROM candles
+WHERE exchange=? AND symbol=? AND timeframe=? AND is_final=1
+ORDER BY timestamp ASC;
+`, exchange, symbol, timeframe)
+}
+
+// LoadCandlesSince returns the closed candles of a series with open time >= fromTimestamp, in
+// time order, for incremental builders that resume after their last processed bar.
+func LoadCandlesSince(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string, fromTimestamp int64) ([]candles.Candle, error) {
+	return queryCandles(ctx, db, `
+SELECT exchange, symbol, timeframe, timestamp,
+       open, high, low, close, volume,
+       close_time, is_final,
+       quote_volume, trades, taker_buy_base_volume, taker_buy_quote_volume,
+       resampled_from
+FROM candles
+WHERE exchange=? AND symbol=? AND timeframe=? AND is_final=1 AND timestamp >= ?
+ORDER BY timestamp ASC;
+`, exchange, symbol, timeframe, fromTimestamp)
+}
+
+// LoadAllCandlesOrdered returns every stored candle of a series in time order, including
+// non-final ones. Only for inspecting or exporting the stored data (audits, export), never for
+// features.
+func LoadAllCandlesOrdered(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string) ([]candles.Candle, error) {
+	return queryCandles(ctx, db, `
 SELECT exchange, symbol, timeframe, timestamp,
        open, high, low, close, volume,
-       close_time, is_final
+       COALESCE(close_time, 0), is_final,
+       quote_volume, trades, taker_buy_base_volume, taker_buy_quote_volume,
+       resampled_from
 FROM candles
 WHERE exchange=? AND symbol=? AND timeframe=?
 ORDER BY timestamp ASC;
 `, exchange, symbol, timeframe)
+}
+
+func queryCandles(ctx context.Context, db *sql.DB, query string, args ...any) ([]candles.Candle, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -25,17 +64,121 @@ ORDER BY timestamp ASC;
 	for rows.Next() {
 		var candle candles.Candle
 		var isFinalInt int
+		var quoteVolume, takerBuyBaseVolume, takerBuyQuoteVolume sql.NullFloat64
+		var trades sql.NullInt64
+		var resampledFrom sql.NullString
 
 		if err := rows.Scan(
 			&candle.Exchange, &candle.Symbol, &candle.Timeframe, &candle.Timestamp,
 			&candle.Open, &candle.High, &candle.Low, &candle.Close, &candle.Volume,
 			&candle.CloseTime, &isFinalInt,
+			&quoteVolume, &trades, &takerBuyBaseVolume, &takerBuyQuoteVolume,
+			&resampledFrom,
 		); err != nil {
 			return nil, err
 		}
 		candle.IsFinal = (isFinalInt == 1)
+		candle.QuoteVolume = nullFloat64Pointer(quoteVolume)
+		candle.Trades = nullInt64Pointer(trades)
+		candle.TakerBuyBaseVolume = nullFloat64Pointer(takerBuyBaseVolume)
+		candle.TakerBuyQuoteVolume = nullFloat64Pointer(takerBuyQuoteVolume)
+		candle.ResampledFrom = resampledFrom.String
 		result = append(result, candle)
 	}
 
 	return result, rows.Err()
 }
+
+// CountExchangeCandles counts the candles of a series that were fetched from the exchange rather
+// than built by resample.
+func CountExchangeCandles(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string) (int, error) {
+	var count int
+	err := db.QueryRowContext(ctx, `
+SELECT COUNT(*)
+FROM candles
+WHERE exchange=? AND symbol=? AND timeframe=? AND resampled_from IS NULL;
+`, exchange, symbol, timeframe).Scan(&count)
+	return count, err
+}
+
+// LatestFinalCandleTimestamp returns the open time of the newest closed candle for the series.
+// A stored in-progress candle is ignored so the next run refetches and finalizes it.
+// found is false when the series has no closed candles yet.
+func LatestFinalCandleTimestamp(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string) (timestamp int64, found bool, err error) {
+	var latest sql.NullInt64
+	err = db.QueryRowContext(ctx, `
+SELECT MAX(timestamp)
+FROM candles
+WHERE exchange=? AND symbol=? AND timeframe=? AND is_final=1;
+`, exchange, symbol, timeframe).Scan(&latest)
+	if err != nil {
+		return 0, false, err
+	}
+	return latest.Int64, latest.Valid, nil
+}
+
+// LatestFinalCandleTimestampBefore returns the open time of the newest closed candle that opened
+// before the given time; found is false when there is none.
+func LatestFinalCandleTimestampBefore(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string, before int64) (timestamp int64, found bool, err error) {
+	var latest sql.NullInt64
+	err = db.QueryRowContext(ctx, `
+SELECT MAX(timestamp)
+FROM candles
+WHERE exchange=? AND symbol=? AND timeframe=? AND is_final=1 AND timestamp < ?;
+`, exchange, symbol, timeframe, before).Scan(&latest)
+	if err != nil {
+		return 0, false, err
+	}
+	return latest.Int64, latest.Valid, nil
+}
+
+// CountFinalCandlesThrough counts the closed candles of a series with open time <= through.
+// Incremental builders compare it with the count they consumed to notice edited history.
+func CountFinalCandlesThrough(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string, through int64) (int, error) {
+	var count int
+	err := db.QueryRowContext(ctx, `
+SELECT COUNT(*)
+FROM candles
+WHERE exchange=? AND symbol=? AND timeframe=? AND is_final=1 AND timestamp <= ?;
+`, exchange, symbol, timeframe, through).Scan(&count)
+	return count, err
+}
+
+// MissingOrderFlowTimestamps returns the open times of closed candles in the series that have
+// no quote_volume yet (rows stored before order-flow fields were tracked), in time order.
+func MissingOrderFlowTimestamps(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string) ([]int64, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT timestamp
+FROM candles
+WHERE exchange=? AND symbol=? AND timeframe=? AND is_final=1 AND quote_volume IS NULL
+ORDER BY timestamp ASC;
+`, exchange, symbol, timeframe)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []int64
+	for rows.Next() {
+		var timestamp int64
+		if err := rows.Scan(&timestamp); err != nil {
+			return nil, err
+		}
+		result = append(result, timestamp)
+	}
+	return result, rows.Err()
+}
+
+func nullFloat64Pointer(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
+
+func nullInt64Pointer(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
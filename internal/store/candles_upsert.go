@@ -29,8 +29,13 @@ INSERT INTO candles (
   close,
   volume,
   close_time,
-  is_final
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+  is_final,
+  quote_volume,
+  trades,
+  taker_buy_base_volume,
+  taker_buy_quote_volume,
+  resampled_from
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))
 ON CONFLICT(exchange, symbol, timeframe, timestamp) DO UPDATE SET
   open       = excluded.open,
   high       = excluded.high,
@@ -38,7 +43,15 @@ ON CONFLICT(exchange, symbol, timeframe, timestamp) DO UPDATE SET
   close      = excluded.close,
   volume     = excluded.volume,
   close_time = excluded.close_time,
-  is_final   = excluded.is_final;
+  is_final   = excluded.is_final,
+  -- a source without order-flow fields must not erase ones stored earlier
+  quote_volume           = COALESCE(excluded.quote_volume, candles.quote_volume),
+  trades                 = COALESCE(excluded.trades, candles.trades),
+  taker_buy_base_volume  = COALESCE(excluded.taker_buy_base_volume, candles.taker_buy_base_volume),
+  taker_buy_quote_volume = COALESCE(excluded.taker_buy_quote_volume, candles.taker_buy_quote_volume),
+  resampled_from         = excluded.resampled_from
+-- a closed bar never goes back to forming (e.g. a partial resampled bar over an exchange one)
+WHERE candles.is_final = 0 OR excluded.is_final = 1;
 `
 
 	transaction, beginError := database.BeginTx(context, nil)
@@ -72,6 +85,11 @@ ON CONFLICT(exchange, symbol, timeframe, timestamp) DO UPDATE SET
 			candle.Volume,
 			candle.CloseTime,
 			isFinalInteger,
+			candle.QuoteVolume,
+			candle.Trades,
+			candle.TakerBuyBaseVolume,
+			candle.TakerBuyQuoteVolume,
+			candle.ResampledFrom,
 		)
 		if execError != nil {
 			return fmt.Errorf(
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"btc-4h-prediction-model/internal/derivatives"
+)
+
+func LoadFundingRatesOrdered(ctx context.Context, db *sql.DB, exchange string, symbol string) ([]derivatives.FundingRate, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT exchange, symbol, funding_time, funding_rate, mark_price
+FROM funding_rates
+WHERE exchange=? AND symbol=?
+ORDER BY funding_time ASC;
+`, exchange, symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []derivatives.FundingRate
+	for rows.Next() {
+		var rate derivatives.FundingRate
+		var markPrice sql.NullFloat64
+		if err := rows.Scan(&rate.Exchange, &rate.Symbol, &rate.FundingTime, &rate.Rate, &markPrice); err != nil {
+			return nil, err
+		}
+		rate.MarkPrice = nullFloat64Pointer(markPrice)
+		result = append(result, rate)
+	}
+	return result, rows.Err()
+}
+
+func LoadOpenInterestOrdered(ctx context.Context, db *sql.DB, exchange string, symbol string, period string) ([]derivatives.OpenInterest, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT exchange, symbol, period, timestamp, sum_open_interest, sum_open_interest_value
+FROM open_interest
+WHERE exchange=? AND symbol=? AND period=?
+ORDER BY timestamp ASC;
+`, exchange, symbol, period)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []derivatives.OpenInterest
+	for rows.Next() {
+		var sample derivatives.OpenInterest
+		if err := rows.Scan(
+			&sample.Exchange, &sample.Symbol, &sample.Period, &sample.Timestamp,
+			&sample.SumOpenInterest, &sample.SumOpenInterestValue,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, sample)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"btc-4h-prediction-model/internal/derivatives"
+)
+
+func UpsertFundingRates(ctx context.Context, db *sql.DB, rates []derivatives.FundingRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	const query = `
+INSERT INTO funding_rates (exchange, symbol, funding_time, funding_rate, mark_price)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(exchange, symbol, funding_time) DO UPDATE SET
+  funding_rate = excluded.funding_rate,
+  mark_price   = COALESCE(excluded.mark_price, funding_rates.mark_price);
+`
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.ExecContext(ctx, rate.Exchange, rate.Symbol, rate.FundingTime, rate.Rate, rate.MarkPrice); err != nil {
+			return fmt.Errorf("upsert funding rate failed funding_time=%d: %w", rate.FundingTime, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func UpsertOpenInterest(ctx context.Context, db *sql.DB, samples []derivatives.OpenInterest) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	const query = `
+INSERT INTO open_interest (exchange, symbol, period, timestamp, sum_open_interest, sum_open_interest_value)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(exchange, symbol, period, timestamp) DO UPDATE SET
+  sum_open_interest       = excluded.sum_open_interest,
+  sum_open_interest_value = excluded.sum_open_interest_value;
+`
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, sample := range samples {
+		if _, err := stmt.ExecContext(
+			ctx,
+			sample.Exchange, sample.Symbol, sample.Period, sample.Timestamp,
+			sample.SumOpenInterest, sample.SumOpenInterestValue,
+		); err != nil {
+			return fmt.Errorf("upsert open interest failed timestamp=%d: %w", sample.Timestamp, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"btc-4h-prediction-model/internal/features"
+)
+
+func LoadFeatureStates(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string) ([]features.StreamState, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT exchange, symbol, timeframe, name, params, last_timestamp, candles, state, updated_at
+FROM feature_state
+WHERE exchange=? AND symbol=? AND timeframe=?
+ORDER BY name ASC, params ASC;
+`, exchange, symbol, timeframe)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []features.StreamState
+	for rows.Next() {
+		var state features.StreamState
+		if err := rows.Scan(
+			&state.Exchange, &state.Symbol, &state.Timeframe, &state.Name, &state.Params,
+			&state.LastTimestamp, &state.Candles, &state.State, &state.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, state)
+	}
+	return result, rows.Err()
+}
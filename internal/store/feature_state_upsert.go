@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"btc-4h-prediction-model/internal/features"
+)
+
+func UpsertFeatureStates(ctx context.Context, db *sql.DB, states []features.StreamState) error {
+	if len(states) == 0 {
+		return nil
+	}
+
+	const query = `
+INSERT INTO feature_state (exchange, symbol, timeframe, name, params, last_timestamp, candles, state, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(exchange, symbol, timeframe, name, params) DO UPDATE SET
+  last_timestamp = excluded.last_timestamp,
+  candles        = excluded.candles,
+  state          = excluded.state,
+  updated_at     = excluded.updated_at;
+`
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, state := range states {
+		if _, err := stmt.ExecContext(
+			ctx,
+			state.Exchange, state.Symbol, state.Timeframe, state.Name, state.Params,
+			state.LastTimestamp, state.Candles, state.State, state.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("upsert feature state failed %s(%s): %w", state.Name, state.Params, err)
+		}
+	}
+
+	return tx.Commit()
+}
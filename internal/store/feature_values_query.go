@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"btc-4h-prediction-model/internal/features"
+)
+
+// LoadFeatureValues returns one registry feature of a series keyed by bar timestamp.
+// Cross-timeframe specs load the values joined onto this series.
+func LoadFeatureValues(
+	ctx context.Context,
+	db *sql.DB,
+	exchange string,
+	symbol string,
+	timeframe string,
+	spec features.Spec,
+) (map[int64]float64, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT timestamp, value
+FROM feature_values
+WHERE exchange=? AND symbol=? AND timeframe=? AND name=? AND params=?;
+`, exchange, symbol, timeframe, spec.ForSeries(timeframe).StoredName(), spec.ParamsString())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[int64]float64{}
+	for rows.Next() {
+		var timestamp int64
+		var value float64
+		if err := rows.Scan(&timestamp, &value); err != nil {
+			return nil, err
+		}
+		result[timestamp] = value
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"btc-4h-prediction-model/internal/features"
+)
+
+func UpsertFeatureValues(ctx context.Context, db *sql.DB, values []features.FeatureValue) error {
+	return ReplaceFeatureValues(ctx, db, "", "", "", nil, values)
+}
+
+// ReplaceFeatureValues deletes every stored value of the restarted specs for the series, then
+// upserts values, in one transaction so a failed run never leaves a restarted spec without its
+// values.
+func ReplaceFeatureValues(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string, restarted []features.Spec, values []features.FeatureValue) error {
+	if len(restarted) == 0 && len(values) == 0 {
+		return nil
+	}
+
+	const query = `
+INSERT INTO feature_values (exchange, symbol, timeframe, timestamp, name, params, value)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(exchange, symbol, timeframe, name, params, timestamp) DO UPDATE SET
+  value = excluded.value;
+`
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, spec := range restarted {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM feature_values WHERE exchange=? AND symbol=? AND timeframe=? AND name=? AND params=?;`,
+			exchange, symbol, timeframe, spec.StoredName(), spec.ParamsString(),
+		); err != nil {
+			return fmt.Errorf("delete feature values %s: %w", spec, err)
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, value := range values {
+		if _, err := stmt.ExecContext(
+			ctx,
+			value.Exchange, value.Symbol, value.Timeframe, value.Timestamp,
+			value.Name, value.Params, value.Value,
+		); err != nil {
+			return fmt.Errorf("upsert feature value failed %s(%s) timestamp=%d: %w", value.Name, value.Params, value.Timestamp, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -9,7 +9,17 @@ import (
 )
 
 func UpsertFeatures(ctx context.Context, db *sql.DB, rows []features.FeatureRow) error {
-	if len(rows) == 0 {
+	return writeFeatures(ctx, db, "", "", "", false, rows)
+}
+
+// ReplaceFeatures deletes every stored feature row of the series, then upserts rows, in one
+// transaction.
+func ReplaceFeatures(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string, rows []features.FeatureRow) error {
+	return writeFeatures(ctx, db, exchange, symbol, timeframe, true, rows)
+}
+
+func writeFeatures(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string, replace bool, rows []features.FeatureRow) error {
+	if !replace && len(rows) == 0 {
 		return nil
 	}
 
@@ -17,8 +27,9 @@ func UpsertFeatures(ctx context.Context, db *sql.DB, rows []features.FeatureRow)
 INSERT INTO features (
   exchange, symbol, timeframe, timestamp,
   ret_1, vol_20, mom_6, ema_10, ema_30, ema_spread,
-  range_hl, range_co, vol_chg
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+  range_hl, range_co, vol_chg,
+  funding_rate, oi_chg
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(exchange, symbol, timeframe, timestamp) DO UPDATE SET
   ret_1      = excluded.ret_1,
   vol_20     = excluded.vol_20,
@@ -28,7 +39,9 @@ ON CONFLICT(exchange, symbol, timeframe, timestamp) DO UPDATE SET
   ema_spread = excluded.ema_spread,
   range_hl   = excluded.range_hl,
   range_co   = excluded.range_co,
-  vol_chg    = excluded.vol_chg;
+  vol_chg    = excluded.vol_chg,
+  funding_rate = excluded.funding_rate,
+  oi_chg       = excluded.oi_chg;
 `
 
 	tx, err := db.BeginTx(ctx, nil)
@@ -37,6 +50,15 @@ ON CONFLICT(exchange, symbol, timeframe, timestamp) DO UPDATE SET
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	if replace {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM features WHERE exchange=? AND symbol=? AND timeframe=?;`,
+			exchange, symbol, timeframe,
+		); err != nil {
+			return fmt.Errorf("delete features: %w", err)
+		}
+	}
+
 	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
 		return err
@@ -49,6 +71,7 @@ ON CONFLICT(exchange, symbol, timeframe, timestamp) DO UPDATE SET
 			row.Exchange, row.Symbol, row.Timeframe, row.Timestamp,
 			row.Ret1, row.Vol20, row.Mom6, row.Ema10, row.Ema30, row.EmaSpread,
 			row.RangeHL, row.RangeCO, row.VolChg,
+			row.FundingRate, row.OIChg,
 		)
 		if execErr != nil {
 			return fmt.Errorf("upsert features failed timestamp=%d: %w", row.Timestamp, execErr)
@@ -57,3 +80,42 @@ ON CONFLICT(exchange, symbol, timeframe, timestamp) DO UPDATE SET
 
 	return tx.Commit()
 }
+
+// UpdateFeatureDerivatives rewrites only funding_rate and oi_chg of existing feature rows, for
+// re-joining funding/open interest history that arrived after the rows were built.
+func UpdateFeatureDerivatives(ctx context.Context, db *sql.DB, rows []features.FeatureRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	const query = `
+UPDATE features SET
+  funding_rate = ?,
+  oi_chg       = ?
+WHERE exchange = ? AND symbol = ? AND timeframe = ? AND timestamp = ?;
+`
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(
+			ctx,
+			row.FundingRate, row.OIChg,
+			row.Exchange, row.Symbol, row.Timeframe, row.Timestamp,
+		); err != nil {
+			return fmt.Errorf("update feature derivatives failed timestamp=%d: %w", row.Timestamp, err)
+		}
+	}
+
+	return tx.Commit()
+}
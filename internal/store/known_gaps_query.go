@@ -0,0 +1,32 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+func LoadKnownGaps(ctx context.Context, db *sql.DB, exchange string, symbol string, timeframe string) ([]KnownGap, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT exchange, symbol, timeframe, start_ts, end_ts, reason, recorded_at
+FROM known_gaps
+WHERE exchange=? AND symbol=? AND timeframe=?
+ORDER BY start_ts ASC;
+`, exchange, symbol, timeframe)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []KnownGap
+	for rows.Next() {
+		var gap KnownGap
+		if err := rows.Scan(
+			&gap.Exchange, &gap.Symbol, &gap.Timeframe, &gap.StartTS, &gap.EndTS,
+			&gap.Reason, &gap.RecordedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, gap)
+	}
+	return result, rows.Err()
+}
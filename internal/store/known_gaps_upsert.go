@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// KnownGap is a missing candle range that was accepted as unfillable.
+type KnownGap struct {
+	Exchange  string
+	Symbol    string
+	Timeframe string
+
+	StartTS int64 // first missing open time
+	EndTS   int64 // open time of the next stored candle
+
+	Reason     string
+	RecordedAt int64 // unix seconds
+}
+
+func UpsertKnownGaps(ctx context.Context, db *sql.DB, gaps []KnownGap) error {
+	if len(gaps) == 0 {
+		return nil
+	}
+
+	const query = `
+INSERT INTO known_gaps (exchange, symbol, timeframe, start_ts, end_ts, reason, recorded_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(exchange, symbol, timeframe, start_ts) DO UPDATE SET
+  end_ts      = excluded.end_ts,
+  reason      = excluded.reason,
+  recorded_at = excluded.recorded_at;
+`
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, gap := range gaps {
+		if _, err := stmt.ExecContext(
+			ctx,
+			gap.Exchange, gap.Symbol, gap.Timeframe, gap.StartTS, gap.EndTS,
+			gap.Reason, gap.RecordedAt,
+		); err != nil {
+			return fmt.Errorf("upsert known gap failed start_ts=%d: %w", gap.StartTS, err)
+		}
+	}
+
+	return tx.Commit()
+}
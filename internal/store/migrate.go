@@ -0,0 +1,211 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"btc-4h-prediction-model/migrations"
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+type MigrationStatus struct {
+	CurrentVersion int
+	LatestVersion  int
+	Baselined      []Migration // found already in place in a pre-migration database, recorded without running
+	Applied        []Migration
+}
+
+// preMigrationObjects are what the first migrations create. Databases built before
+// schema_migrations existed had these files applied by hand, so their objects mark the
+// migrations to record rather than run.
+var preMigrationObjects = []struct {
+	version int
+	kind    string
+	name    string
+}{
+	{1, "table", "candles"},
+	{2, "table", "features"},
+	{3, "table", "labels"},
+	{4, "view", "dataset"},
+	{5, "table", "predictions"},
+}
+
+// LoadMigrations reads the embedded migration files ordered by version.
+func LoadMigrations() ([]Migration, error) {
+	fileNames, err := fs.Glob(migrations.Files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Migration
+	seenVersions := map[int]string{}
+
+	for _, fileName := range fileNames {
+		versionPart, namePart, found := strings.Cut(strings.TrimSuffix(fileName, ".sql"), "_")
+		if !found {
+			return nil, fmt.Errorf("migration %s: expected NNNNN_name.sql", fileName)
+		}
+		version, err := strconv.Atoi(versionPart)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", fileName, versionPart)
+		}
+		if previous, duplicate := seenVersions[version]; duplicate {
+			return nil, fmt.Errorf("migration version %d used by both %s and %s", version, previous, fileName)
+		}
+		seenVersions[version] = fileName
+
+		body, err := fs.ReadFile(migrations.Files, fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, Migration{Version: version, Name: namePart, SQL: string(body)})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// Migrate applies pending embedded migrations in version order, one transaction per migration.
+// It refuses to touch a database whose schema version is newer than the newest embedded migration.
+func Migrate(ctx context.Context, db *sql.DB) (MigrationStatus, error) {
+	available, err := LoadMigrations()
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	var status MigrationStatus
+	if len(available) > 0 {
+		status.LatestVersion = available[len(available)-1].Version
+	}
+
+	if _, err := db.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+  version    INTEGER PRIMARY KEY,
+  name       TEXT NOT NULL,
+  applied_at INTEGER NOT NULL
+);`); err != nil {
+		return MigrationStatus{}, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	currentVersion, err := currentSchemaVersion(ctx, db)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	if currentVersion == 0 {
+		if status.Baselined, err = baselinePreMigrationSchema(ctx, db, available); err != nil {
+			return MigrationStatus{}, err
+		}
+		if len(status.Baselined) > 0 {
+			currentVersion = status.Baselined[len(status.Baselined)-1].Version
+		}
+	}
+	if currentVersion > status.LatestVersion {
+		return MigrationStatus{}, fmt.Errorf(
+			"database schema version %d is newer than this binary supports (%d); upgrade the binary",
+			currentVersion, status.LatestVersion,
+		)
+	}
+
+	for _, migration := range available {
+		if migration.Version <= currentVersion {
+			continue
+		}
+		if err := applyMigration(ctx, db, migration); err != nil {
+			return MigrationStatus{}, err
+		}
+		status.Applied = append(status.Applied, migration)
+		currentVersion = migration.Version
+	}
+
+	status.CurrentVersion = currentVersion
+	return status, nil
+}
+
+func currentSchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations;`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// baselinePreMigrationSchema records the leading migrations whose objects already exist in a
+// database with an empty schema_migrations, so Migrate resumes after them.
+func baselinePreMigrationSchema(ctx context.Context, db *sql.DB, available []Migration) ([]Migration, error) {
+	byVersion := map[int]Migration{}
+	for _, migration := range available {
+		byVersion[migration.Version] = migration
+	}
+
+	var baselined []Migration
+	for _, object := range preMigrationObjects {
+		var count int
+		if err := db.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM sqlite_master WHERE type = ? AND name = ?;`,
+			object.kind, object.name,
+		).Scan(&count); err != nil {
+			return nil, fmt.Errorf("detect pre-migration schema: %w", err)
+		}
+		migration, ok := byVersion[object.version]
+		if count == 0 || !ok {
+			break
+		}
+		baselined = append(baselined, migration)
+	}
+	if len(baselined) == 0 {
+		return nil, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, migration := range baselined {
+		if err := recordMigration(ctx, tx, migration); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return baselined, nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, migration Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, migration.SQL); err != nil {
+		return fmt.Errorf("apply migration %05d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if err := recordMigration(ctx, tx, migration); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func recordMigration(ctx context.Context, tx *sql.Tx, migration Migration) error {
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, strftime('%s','now'));`,
+		migration.Version, migration.Name,
+	); err != nil {
+		return fmt.Errorf("record migration %05d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
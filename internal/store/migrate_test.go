@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func openTempDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := OpenSQLiteWithoutMigrations(filepath.Join(t.TempDir(), "btcqd.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func migrationVersions(migrations []Migration) []int {
+	versions := []int{}
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	return versions
+}
+
+func recordedVersions(t *testing.T, database *sql.DB) []int {
+	t.Helper()
+	rows, err := database.Query(`SELECT version FROM schema_migrations ORDER BY rowid;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	versions := []int{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return versions
+}
+
+func TestMigrateAppliesInOrderAndIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	available, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := openTempDatabase(t)
+
+	status, err := Migrate(ctx, database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := migrationVersions(available)
+	for i := 1; i < len(want); i++ {
+		if want[i] <= want[i-1] {
+			t.Fatalf("LoadMigrations is not ordered by version: %v", want)
+		}
+	}
+	if got := migrationVersions(status.Applied); !reflect.DeepEqual(got, want) {
+		t.Fatalf("applied %v, want %v", got, want)
+	}
+	if got := recordedVersions(t, database); !reflect.DeepEqual(got, want) {
+		t.Fatalf("schema_migrations holds %v, want %v in application order", got, want)
+	}
+	if status.CurrentVersion != status.LatestVersion || status.LatestVersion != want[len(want)-1] {
+		t.Fatalf("status = %+v, want current = latest = %d", status, want[len(want)-1])
+	}
+
+	rerun, err := Migrate(ctx, database)
+	if err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	if len(rerun.Applied) != 0 || len(rerun.Baselined) != 0 || rerun.CurrentVersion != status.LatestVersion {
+		t.Fatalf("rerun status = %+v, want nothing applied at version %d", rerun, status.LatestVersion)
+	}
+	if got := recordedVersions(t, database); !reflect.DeepEqual(got, want) {
+		t.Fatalf("rerun changed schema_migrations to %v", got)
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	ctx := context.Background()
+	database := openTempDatabase(t)
+	status, err := Migrate(ctx, database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'from_a_newer_binary', 0);`,
+		status.LatestVersion+1,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Migrate(ctx, database)
+	if err == nil || !strings.Contains(err.Error(), "newer than this binary supports") {
+		t.Fatalf("err = %v, want a refusal of the newer schema", err)
+	}
+}
+
+func TestMigrateBaselinesPreMigrationDatabase(t *testing.T) {
+	ctx := context.Background()
+	available, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		handBuilt int // leading migration files applied by hand, without schema_migrations
+	}{
+		{"candles only", 1},
+		{"every pre-migration file", len(preMigrationObjects)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			database := openTempDatabase(t)
+			for _, migration := range available[:test.handBuilt] {
+				if _, err := database.Exec(migration.SQL); err != nil {
+					t.Fatalf("hand-apply %05d_%s: %v", migration.Version, migration.Name, err)
+				}
+			}
+			if _, err := database.Exec(`
+INSERT INTO candles (exchange, symbol, timeframe, timestamp, open, high, low, close, volume, close_time, is_final)
+VALUES ('binance', 'BTCUSDT', '4h', 1709251200000, 1, 2, 0.5, 1.5, 10, 1709265599999, 1);`); err != nil {
+				t.Fatal(err)
+			}
+
+			status, err := Migrate(ctx, database)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := migrationVersions(status.Baselined), migrationVersions(available[:test.handBuilt]); !reflect.DeepEqual(got, want) {
+				t.Fatalf("baselined %v, want %v", got, want)
+			}
+			if got, want := migrationVersions(status.Applied), migrationVersions(available[test.handBuilt:]); !reflect.DeepEqual(got, want) {
+				t.Fatalf("applied %v, want %v", got, want)
+			}
+			if status.CurrentVersion != status.LatestVersion {
+				t.Fatalf("schema version %d, want %d", status.CurrentVersion, status.LatestVersion)
+			}
+
+			// The existing candle survives and picks up the later order-flow columns as NULL
+			var close float64
+			var trades sql.NullInt64
+			if err := database.QueryRow(`SELECT close, trades FROM candles;`).Scan(&close, &trades); err != nil {
+				t.Fatal(err)
+			}
+			if close != 1.5 || trades.Valid {
+				t.Fatalf("candle close %v trades %v, want 1.5 and NULL", close, trades)
+			}
+		})
+	}
+}
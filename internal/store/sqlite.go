@@ -1,13 +1,27 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
 	_ "modernc.org/sqlite"
 )
 
+// OpenSQLite opens the database and brings its schema up to date with the embedded migrations.
 func OpenSQLite(path string) (*sql.DB, error) {
+	database, err := OpenSQLiteWithoutMigrations(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Migrate(context.Background(), database); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("migrate db: %w", err)
+	}
+	return database, nil
+}
+
+func OpenSQLiteWithoutMigrations(path string) (*sql.DB, error) {
 	database, err := sql.Open("sqlite", path)
 	if err != nil {
 		return nil, err
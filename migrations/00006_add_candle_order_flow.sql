@@ -0,0 +1,4 @@
+ALTER TABLE candles ADD COLUMN quote_volume REAL;
+ALTER TABLE candles ADD COLUMN trades INTEGER;
+ALTER TABLE candles ADD COLUMN taker_buy_base_volume REAL;
+ALTER TABLE candles ADD COLUMN taker_buy_quote_volume REAL;
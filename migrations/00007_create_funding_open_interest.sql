@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS funding_rates (
+  exchange     TEXT NOT NULL,
+  symbol       TEXT NOT NULL,
+  funding_time INTEGER NOT NULL,
+
+  funding_rate REAL NOT NULL,
+  mark_price   REAL,
+
+  PRIMARY KEY (exchange, symbol, funding_time)
+);
+
+CREATE TABLE IF NOT EXISTS open_interest (
+  exchange  TEXT NOT NULL,
+  symbol    TEXT NOT NULL,
+  period    TEXT NOT NULL,
+  timestamp INTEGER NOT NULL,
+
+  sum_open_interest       REAL NOT NULL,
+  sum_open_interest_value REAL NOT NULL,
+
+  PRIMARY KEY (exchange, symbol, period, timestamp),
+  CHECK (sum_open_interest >= 0)
+);
+
+-- As-of joined onto the candle grid; nullable and kept out of the dataset view
+-- because history is shorter than the candle history.
+ALTER TABLE features ADD COLUMN funding_rate REAL;
+ALTER TABLE features ADD COLUMN oi_chg REAL;
@@ -0,0 +1,15 @@
+-- Missing candle ranges the exchange cannot fill (e.g. maintenance outages).
+-- start_ts is the first missing open time, end_ts the open time of the next stored candle.
+CREATE TABLE IF NOT EXISTS known_gaps (
+  exchange    TEXT NOT NULL,
+  symbol      TEXT NOT NULL,
+  timeframe   TEXT NOT NULL,
+  start_ts    INTEGER NOT NULL,
+  end_ts      INTEGER NOT NULL,
+
+  reason      TEXT NOT NULL,
+  recorded_at INTEGER NOT NULL,
+
+  PRIMARY KEY (exchange, symbol, timeframe, start_ts),
+  CHECK (end_ts > start_ts)
+);
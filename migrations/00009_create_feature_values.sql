@@ -0,0 +1,14 @@
+-- Registry features in long format: one row per bar and feature spec, e.g. name='ema', params='20'.
+-- Bars still in a feature's warm-up have no row.
+CREATE TABLE IF NOT EXISTS feature_values (
+  exchange  TEXT NOT NULL,
+  symbol    TEXT NOT NULL,
+  timeframe TEXT NOT NULL,
+  timestamp INTEGER NOT NULL,
+
+  name      TEXT NOT NULL,
+  params    TEXT NOT NULL,
+  value     REAL NOT NULL,
+
+  PRIMARY KEY (exchange, symbol, timeframe, name, params, timestamp)
+);
@@ -0,0 +1,20 @@
+-- Streaming state saved by quant features so the next run only processes new bars.
+-- One row per series and feature stream: name='features' for the fixed feature columns,
+-- otherwise the feature_values name/params of a registry feature.
+-- last_timestamp/candles describe the candles consumed from the stream's source series (the
+-- other timeframe for cross-timeframe features); a different count means history was edited
+-- and the stream is rebuilt.
+CREATE TABLE IF NOT EXISTS feature_state (
+  exchange       TEXT NOT NULL,
+  symbol         TEXT NOT NULL,
+  timeframe      TEXT NOT NULL,
+  name           TEXT NOT NULL,
+  params         TEXT NOT NULL,
+
+  last_timestamp INTEGER NOT NULL,
+  candles        INTEGER NOT NULL,
+  state          TEXT NOT NULL, -- JSON
+  updated_at     INTEGER NOT NULL,
+
+  PRIMARY KEY (exchange, symbol, timeframe, name, params)
+);
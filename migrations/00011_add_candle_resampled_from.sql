@@ -0,0 +1,3 @@
+-- Source timeframe of bars built by `resample` from lower-timeframe candles; NULL for bars
+-- fetched from the exchange.
+ALTER TABLE candles ADD COLUMN resampled_from TEXT;
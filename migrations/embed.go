@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// Files holds the versioned SQL migrations (NNNNN_name.sql) applied by store.Migrate.
+//
+//go:embed *.sql
+var Files embed.FS